@@ -3,19 +3,117 @@ package argparse
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
 	"strings"
+	"text/template"
 )
 
+// ErrorHandling defines how Parse behaves when parsing fails or help is
+// requested, mirroring the flag package
+type ErrorHandling int
+
+const (
+	// ContinueOnError returns the error, ErrHelp for help
+	ContinueOnError ErrorHandling = iota
+	// ExitOnError exits with status 0 after help or version and 2 after
+	// printing any other error
+	ExitOnError
+	// PanicOnError panics with the error
+	PanicOnError
+)
+
+// exit is os.Exit, replaced in tests
+var exit = os.Exit
+
 type Parser struct {
-	args     []*arg
-	showHelp bool
-	parsed   bool
+	args        []*arg
+	showHelp    bool
+	showVersion bool
+	parsed      bool
+	// name index of args, filled by addArg
+	snames map[string]*arg
+	lnames map[string]*arg
+	// argv elements left after parsing
+	rest []string
+	// collect unknown options instead of failing, see ParseKnownArgs
+	knownOnly bool
+	unknown   []string
+	// "did you mean" settings, see SetSuggestions
+	noSuggest       bool
+	suggestDistance int
+	// accept unambiguous prefixes of long names, see SetAbbreviations
+	abbrev bool
+	// subcommand tree, see AddCommand
+	name        string
+	description string
+	parent      *Parser
+	commands    []*Parser
+	command     *Parser
+	handler     Handler
+	aliases     []string
+	hidden      bool
+	deprecated  string
+	// external commands on PATH, see SetPlugins
+	plugins  bool
+	plugin   string
+	progName string
+	// see MutuallyExclusive
+	exclusive []*exclusiveGroup
+	// help sections, see Group
+	groups []*Group
+	// help text width, see SetWidth
+	width int
+	// see SetErrorHandling and SetOutput
+	errorHandling ErrorHandling
+	output        io.Writer
+	// see SetHelpTemplate
+	helpTemplate *template.Template
+	// help text around the options, see SetEpilog, AddExample and SetUsage
+	epilog    string
+	examples  []example
+	usageText string
+	// see Version
+	versionText      string
+	versionBuildInfo bool
 }
 
 type Option struct {
 	Require bool
+	// Negatable adds a "--no-<long>" counterpart to a Bool option
+	Negatable bool
+	// Max caps the value of a Count option, 0 means no limit
+	Max int
+	// Persistent options are accepted by every subcommand below the parser
+	Persistent bool
+	// Requires lists options, by long or short name, that must be given
+	// along with this one
+	Requires []string
+	// ConflictsWith lists options that must not be given along with this one
+	ConflictsWith []string
+	// RequiredIf makes the option required when any of the listed ones is
+	// given
+	RequiredIf []string
+	// RequiredUnless makes the option required when none of the listed ones
+	// is given
+	RequiredUnless []string
+	// Group puts the option in a help section created with Parser.Group
+	Group *Group
+	// Metavar names the value in help, e.g. "PORT" in "--port PORT". It
+	// defaults to the upper cased option name.
+	Metavar string
+	// Choices lists the values the option accepts, as written on the
+	// command line
+	Choices []string
+	// HideDefault keeps the default value out of help
+	HideDefault bool
+	// DefaultText is shown in help instead of the default value, e.g.
+	// "$HOME/.config" for a default computed from the environment
+	DefaultText string
+	// Env names an environment variable the value is read from when the
+	// option is not given on the command line. Slices are comma separated.
+	Env string
 }
 
 type arg struct {
@@ -28,7 +126,15 @@ type arg struct {
 	description  string
 	unique       bool
 	parsed       bool
-	opts         *Option
+	positional   bool
+	// counter args add one to an int every time they are given
+	counter bool
+	// the options registered by Help and Version
+	help    bool
+	version bool
+	// the value was read from the Env variable, not the command line
+	fromEnv bool
+	opts    *Option
 }
 
 func New() *Parser {
@@ -38,10 +144,11 @@ func New() *Parser {
 }
 
 func (p *Parser) addArg(a *arg) error {
-	for _, v := range p.args {
-		if v.sname == a.sname || v.lname == a.lname {
-			return errors.New("option name dup")
-		}
+	if err := p.checkTree(a); err != nil {
+		return err
+	}
+	if err := p.indexArg(a); err != nil {
+		return err
 	}
 	p.args = append(p.args, a)
 
@@ -68,27 +175,194 @@ func (p *Parser) typeVar(i interface{}, defVal interface{}, short, long, descrip
 		opts:         opts,
 	}
 
+	if opts != nil && opts.Negatable {
+		if _, ok := i.(*bool); !ok || long == "" {
+			panic(errors.New("only bool options with a long name are negatable"))
+		}
+	}
+
 	if err := p.addArg(a); err != nil {
 		panic(fmt.Errorf("unable to add String: %s\n", err))
 	}
+	p.addToGroup(a)
 }
 
+// Help adds the option showing usage help. It is persistent, so every
+// subcommand accepts it and shows its own help.
 func (p *Parser) Help(short, long string) {
-	p.typeVar(&p.showHelp, false, short, long, "show usage help", 0, true, nil)
+	p.builtinArg(&arg{sname: short, lname: long, description: "show usage help", help: true})
+}
+
+// builtinArg registers the persistent flag behind Help or Version. Commands
+// may register their own under the same names, which then shadows the
+// inherited one.
+func (p *Parser) builtinArg(a *arg) {
+	if a.sname == "" && a.lname == "" {
+		panic(errors.New("short name and long name is empty"))
+	}
+
+	var show bool
+	a.value = &show
+	a.defaultValue = false
+	a.unique = true
+	a.opts = &Option{Persistent: true}
+	if err := p.addArg(a); err != nil {
+		panic(fmt.Errorf("unable to add Bool: %s\n", err))
+	}
+	p.addToGroup(a)
+}
+
+func (p *Parser) printHelp() error {
+	if p.helpTemplateOf() == nil {
+		_, err := fmt.Fprint(p.Output(), p.helpText())
+		return err
+	}
+
+	s, err := p.executeHelp()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(p.Output(), s)
+	return err
+}
+
+// SetErrorHandling sets what Parse does on errors and help, ContinueOnError
+// by default. Set on the root parser it covers all commands.
+func (p *Parser) SetErrorHandling(h ErrorHandling) {
+	p.errorHandling = h
+}
+
+// SetOutput sets where help and errors are written. By default help goes to
+// stdout and errors to stderr. Set on the root parser it covers all commands.
+func (p *Parser) SetOutput(w io.Writer) {
+	p.output = w
+}
+
+// Output returns the writer help is written to
+func (p *Parser) Output() io.Writer {
+	if w := p.root().output; w != nil {
+		return w
+	}
+	return os.Stdout
+}
+
+// errorOutput returns the writer errors and warnings are written to
+func (p *Parser) errorOutput() io.Writer {
+	if w := p.root().output; w != nil {
+		return w
+	}
+	return os.Stderr
+}
+
+// handleError applies the error handling mode to an error from parse
+func (p *Parser) handleError(err error) error {
+	switch p.root().errorHandling {
+	case ExitOnError:
+		if err == ErrHelp || err == ErrVersion {
+			exit(0)
+			return err
+		}
+		fmt.Fprintln(p.errorOutput(), err)
+		exit(2)
+	case PanicOnError:
+		panic(err)
+	}
+	return err
+}
+
+// notes returns the remarks shown after the description of a in help
+func (p *Parser) notes(a *arg) []string {
+	notes := make([]string, 0)
+	if a.opts != nil && a.opts.Require {
+		notes = append(notes, "required")
+	}
+	if a.opts != nil && len(a.opts.Choices) > 0 {
+		notes = append(notes, fmt.Sprintf("choices: %s", strings.Join(a.opts.Choices, ", ")))
+	}
+	if d := a.defaultText(); d != "" {
+		notes = append(notes, fmt.Sprintf("default: %s", d))
+	}
+	if a.counter {
+		notes = append(notes, "repeatable")
+	}
+	if a.opts != nil && a.opts.Env != "" {
+		notes = append(notes, fmt.Sprintf("env: $%s", a.opts.Env))
+	}
+	return append(notes, p.constraintNotes(a)...)
+}
+
+// SetAbbreviations lets long options be given as any unambiguous prefix of
+// their name, e.g. "--conf" for "--config". An exact match always wins. Set
+// on the root parser it covers all commands.
+func (p *Parser) SetAbbreviations(enabled bool) {
+	p.abbrev = enabled
+}
+
+// SetProg sets the program name shown in help, the base name of os.Args[0]
+// by default. Set on the root parser it covers all commands.
+func (p *Parser) SetProg(name string) {
+	p.progName = name
+}
+
+// SetDescription sets the text shown below the usage line in help
+func (p *Parser) SetDescription(description string) {
+	p.description = description
+}
+
+// SetEpilog sets the text shown at the end of help
+func (p *Parser) SetEpilog(epilog string) {
+	p.epilog = epilog
+}
+
+// AddExample adds a command line with a description of what it does to the
+// examples section of help
+func (p *Parser) AddExample(command, description string) {
+	p.examples = append(p.examples, example{command: command, description: description})
+}
+
+// SetUsage replaces the generated synopsis after "Usage: ", e.g. with
+// "tool [-v] deploy ENV"
+func (p *Parser) SetUsage(usage string) {
+	p.usageText = usage
+}
+
+type example struct {
+	command     string
+	description string
+}
+
+// synopsis returns the full usage line of p without the "Usage: " prefix
+func (p *Parser) synopsis() string {
+	if p.usageText != "" {
+		return p.usageText
+	}
+	return strings.Join(strings.Fields(p.prog()+" "+p.path()+" "+p.usage()), " ")
 }
 
-func (p *Parser) printHelp() {
-	sb := &strings.Builder{}
-	sb.WriteString("Usage:\n")
+// usage returns the synopsis of the parser, e.g. "[options] SRC FILES..."
+func (p *Parser) usage() string {
+	parts := make([]string, 0)
+	options := len(p.inherited()) > 0
 	for _, v := range p.args {
-		if _, err := sb.WriteString(fmt.Sprintf("\t%s\t%s\t\t%s\n", v.name(), v.getType(), v.description)); err != nil {
-			panic(err)
+		options = options || !v.positional
+	}
+	if options {
+		parts = append(parts, "[options]")
+	}
+	for _, g := range p.exclusive {
+		parts = append(parts, g.usage())
+	}
+	if len(p.commands) > 0 {
+		parts = append(parts, "<command>")
+	}
+	for _, v := range p.positionals() {
+		if v.opts != nil && v.opts.Require {
+			parts = append(parts, v.name())
+		} else {
+			parts = append(parts, fmt.Sprintf("[%s]", v.name()))
 		}
 	}
-	fmt.Println(sb.String())
-	// if flag.Lookup("test.v") == nil {
-	os.Exit(2)
-	// }
+	return strings.Join(parts, " ")
 }
 
 func (p *Parser) Parse(a []string) error {
@@ -97,34 +371,94 @@ func (p *Parser) Parse(a []string) error {
 
 	err := p.parse(&copyArg)
 	if err != nil {
-		return err
+		return p.handleError(err)
 	}
 
 	return nil
 }
 
+// ParseKnownArgs is like Parse but does not fail on unknown options, they are
+// returned in the order they were given instead. Values following an unknown
+// option can not be told apart from arguments and are left in Args.
+func (p *Parser) ParseKnownArgs(a []string) ([]string, error) {
+	p.knownOnly = true
+	p.unknown = make([]string, 0)
+
+	if err := p.Parse(a); err != nil {
+		return nil, err
+	}
+
+	return p.unknown, nil
+}
+
+// Args returns the arguments no option or positional consumed, in the order
+// they were given. Everything after "--" is passed through verbatim.
+func (p *Parser) Args() []string {
+	return p.rest
+}
+
+// NArg is the number of arguments remaining after parsing
+func (p *Parser) NArg() int {
+	return len(p.rest)
+}
+
+// Arg returns the i'th remaining argument, or "" if it does not exist
+func (p *Parser) Arg(i int) string {
+	if i < 0 || i >= len(p.rest) {
+		return ""
+	}
+	return p.rest[i]
+}
+
 func (p *Parser) parse(args *[]string) error {
 	if p.parsed {
 		return nil
 	}
-	if len(p.args) < 1 {
-		return nil
+	if p.parent == nil {
+		p.checkConstraintNames()
 	}
 
 	err := p.parseArguemtns(args)
 	if err != nil {
 		return err
 	}
+	if p.command == nil {
+		p.rest = *args
+	}
 
 	p.parsed = true
 
 	if p.showHelp == true {
-		p.printHelp()
+		if err := p.printHelp(); err != nil {
+			return err
+		}
+		return ErrHelp
+	}
+	if p.showVersion {
+		if err := p.printVersion(); err != nil {
+			return err
+		}
+		return ErrVersion
+	}
+
+	if p.command != nil {
+		if err := p.command.parse(args); err != nil {
+			return err
+		}
 	}
 
+	if err := p.parseEnv(); err != nil {
+		return err
+	}
 	p.setDefaultValue()
 
-	return p.checkRequired()
+	if err := p.checkRequired(); err != nil {
+		return err
+	}
+	if err := p.checkExclusive(); err != nil {
+		return err
+	}
+	return p.checkConstraints()
 }
 
 func (p *Parser) setDefaultValue() {
@@ -141,6 +475,23 @@ func (p *Parser) setDefaultValue() {
 	}
 }
 
+// parseEnv sets the args not given on the command line from their Env
+// variable. The command line overrides the environment: an arg is left
+// alone when one it conflicts with was given.
+func (p *Parser) parseEnv() error {
+	for _, v := range p.args {
+		if v.parsed || v.opts == nil || v.opts.Env == "" || p.overridden(v) {
+			continue
+		}
+		if s, ok := os.LookupEnv(v.opts.Env); ok {
+			if err := v.parseEnv(s); err != nil {
+				return fmt.Errorf("$%s: %v", v.opts.Env, err)
+			}
+		}
+	}
+	return nil
+}
+
 func (p *Parser) checkRequired() (err error) {
 	for _, v := range p.args {
 		if v.opts != nil && v.opts.Require && !v.parsed {
@@ -151,40 +502,57 @@ func (p *Parser) checkRequired() (err error) {
 }
 
 func (p *Parser) parseArguemtns(args *[]string) error {
-	n := len(p.args)
-
-	for i := 0; i < n; i++ {
-		nn := len(*args)
-		oarg := p.args[i]
+	tokens, err := p.tokenize(*args)
+	if err != nil {
+		return err
+	}
 
-		for j := 0; j < nn; j++ {
-			s := (*args)[j]
-			if s == "" {
-				continue
-			}
-			ct, err := oarg.check(s)
-			if err != nil {
-				return err
-			}
-			if ct > 0 {
-				valpos := j + 1
-				if len(*args) < valpos+oarg.size {
-					return fmt.Errorf("no enough arguments for %s", oarg.name())
-				}
-				err := oarg.parse((*args)[valpos : valpos+oarg.size])
-				if err != nil {
-					return err
-				}
-
-				oarg.reduce(j, args)
-				continue
+	rest := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		if t.kind == argumentToken {
+			rest = append(rest, t.values...)
+			continue
+		}
+		if t.kind == unknownToken {
+			r := p.root()
+			if !r.knownOnly {
+				return &UnknownOptionError{Option: t.name, Suggestions: p.suggestOptions(t.name)}
 			}
+			r.unknown = append(r.unknown, t.values...)
+			continue
+		}
+		if t.kind == pluginToken {
+			p.plugin = t.name
+			*args = t.values
+			return nil
+		}
+		if t.kind == commandToken {
+			p.command = p.findCommand(t.name)
+			p.command.warnDeprecated(p.errorOutput())
+			rest = t.values
+			break
 		}
-		// if oarg.opts != nil && oarg.opts.Require && !oarg.parsed {
-		//   return fmt.Errorf("[%s] is required", oarg.name())
-		// }
+		if err := t.arg.parse(t.values); err != nil {
+			return err
+		}
+		if t.arg.help {
+			p.showHelp = true
+		}
+		if t.arg.version {
+			p.showVersion = true
+		}
+	}
 
+	if p.command != nil {
+		*args = rest
+		return nil
+	}
+
+	rest, err = p.bindPositionals(rest)
+	if err != nil {
+		return err
 	}
+	*args = rest
 
 	return nil
 }
@@ -222,6 +590,20 @@ func (p *Parser) BoolVar(i *bool, defaultValue bool, short, long, description st
 	p.typeVar(i, defaultValue, short, long, description, 0, true, opts)
 }
 
+// Count adds an option counting how often it is given on top of its default,
+// e.g. "-vvv" is 3 with a default of 0
+func (p *Parser) Count(defaultValue int, short, long, description string, opts *Option) *int {
+	var result int
+
+	p.CountVar(&result, defaultValue, short, long, description, opts)
+
+	return &result
+}
+func (p *Parser) CountVar(i *int, defaultValue int, short, long, description string, opts *Option) {
+	p.typeVar(i, defaultValue, short, long, description, 0, false, opts)
+	p.args[len(p.args)-1].counter = true
+}
+
 func (p *Parser) Float(defaultValue float64, short, long, description string, opts *Option) *float64 {
 	var result float64
 
@@ -1,7 +1,13 @@
 package argparse
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -49,13 +55,39 @@ func TestParser_addArg(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "test addArg with duplicate long name",
+			fields: fields{
+				args:   []*arg{&arg{sname: "a", lname: "all"}},
+				parsed: false,
+			},
+			args: args{
+				a: &arg{sname: "b", lname: "all"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "test addArg with two long only names",
+			fields: fields{
+				args:   []*arg{&arg{lname: "all"}},
+				parsed: false,
+			},
+			args: args{
+				a: &arg{lname: "any"},
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p := &Parser{
-				args:   tt.fields.args,
 				parsed: tt.fields.parsed,
 			}
+			for _, a := range tt.fields.args {
+				if err := p.addArg(a); err != nil {
+					t.Fatal(err)
+				}
+			}
 			if err := p.addArg(tt.args.a); (err != nil) != tt.wantErr {
 				t.Errorf("Parser.addArg() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -153,9 +185,13 @@ func TestParser_Parse(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p := &Parser{
-				args:   tt.fields.args,
 				parsed: tt.fields.parsed,
 			}
+			for _, a := range tt.fields.args {
+				if err := p.addArg(a); err != nil {
+					t.Fatal(err)
+				}
+			}
 			if err := p.Parse(tt.args.a); (err != nil) != tt.wantErr {
 				t.Errorf("Parser.Parse() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -163,6 +199,150 @@ func TestParser_Parse(t *testing.T) {
 	}
 }
 
+func TestParser_ParseAttachedValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantPort  int
+		wantItems []string
+	}{
+		{
+			name:      "test parse long name with equals sign",
+			args:      []string{"--port=3000", "--item=a", "--item=b=c"},
+			wantPort:  3000,
+			wantItems: []string{"a", "b=c"},
+		},
+		{
+			name:      "test parse short name with glued value",
+			args:      []string{"-p3000", "-ia", "-i", "b"},
+			wantPort:  3000,
+			wantItems: []string{"a", "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			port := p.Int(0, "p", "port", "", nil)
+			items := p.StringSlice([]string{}, "i", "item", "", nil)
+			if err := p.Parse(tt.args); err != nil {
+				t.Fatalf("Parser.Parse() error = %v", err)
+			}
+			if *port != tt.wantPort {
+				t.Errorf("port = %v, want %v", *port, tt.wantPort)
+			}
+			if !reflect.DeepEqual(*items, tt.wantItems) {
+				t.Errorf("items = %v, want %v", *items, tt.wantItems)
+			}
+		})
+	}
+}
+
+func TestParser_Args(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantSrc string
+		want    []string
+	}{
+		{
+			name:    "test remaining arguments in order",
+			args:    []string{"a", "-p", "3000", "b", "c"},
+			wantSrc: "a",
+			want:    []string{"b", "c"},
+		},
+		{
+			name:    "test arguments after end of options marker",
+			args:    []string{"--", "-p", "3000", "--", "-x"},
+			wantSrc: "-p",
+			want:    []string{"3000", "--", "-x"},
+		},
+		{
+			name: "test no remaining arguments",
+			args: []string{},
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			port := p.Int(0, "p", "port", "", nil)
+			src := p.PositionalString("", "SRC", "", nil)
+			if err := p.Parse(tt.args); err != nil {
+				t.Fatalf("Parser.Parse() error = %v", err)
+			}
+			if *src != tt.wantSrc {
+				t.Errorf("SRC = %v, want %v", *src, tt.wantSrc)
+			}
+			if *port != 0 && tt.args[0] == "--" {
+				t.Errorf("port = %v, want 0", *port)
+			}
+			if got := p.Args(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parser.Args() = %v, want %v", got, tt.want)
+			}
+			if got := p.NArg(); got != len(tt.want) {
+				t.Errorf("Parser.NArg() = %v, want %v", got, len(tt.want))
+			}
+			if got := p.Arg(len(tt.want)); got != "" {
+				t.Errorf("Parser.Arg() = %v, want empty", got)
+			}
+		})
+	}
+}
+
+func TestParser_ParseKnownArgs(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantUnknown []string
+		wantArgs    []string
+	}{
+		{
+			name:        "test parse known args",
+			args:        []string{"--prot=3000", "-p", "80", "-x", "file", "--", "--other"},
+			wantUnknown: []string{"--prot=3000", "-x"},
+			wantArgs:    []string{"file", "--other"},
+		},
+		{
+			name:        "test parse known args without unknown options",
+			args:        []string{"-p", "80"},
+			wantUnknown: []string{},
+			wantArgs:    []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			port := p.Int(0, "p", "port", "", nil)
+			got, err := p.ParseKnownArgs(tt.args)
+			if err != nil {
+				t.Fatalf("Parser.ParseKnownArgs() error = %v", err)
+			}
+			if *port != 80 {
+				t.Errorf("port = %v, want 80", *port)
+			}
+			if !reflect.DeepEqual(got, tt.wantUnknown) {
+				t.Errorf("Parser.ParseKnownArgs() = %v, want %v", got, tt.wantUnknown)
+			}
+			if !reflect.DeepEqual(p.Args(), tt.wantArgs) {
+				t.Errorf("Parser.Args() = %v, want %v", p.Args(), tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestParser_ParseUnknownOption(t *testing.T) {
+	p := New()
+	p.Int(0, "p", "port", "", nil)
+	err := p.Parse([]string{"--prot=3000"})
+	e, ok := err.(*UnknownOptionError)
+	if !ok {
+		t.Fatalf("Parser.Parse() error = %v, want *UnknownOptionError", err)
+	}
+	if e.Option != "--prot" {
+		t.Errorf("UnknownOptionError.Option = %v, want --prot", e.Option)
+	}
+}
+
 func TestParser_parse(t *testing.T) {
 	var conf string
 	type fields struct {
@@ -197,9 +377,13 @@ func TestParser_parse(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p := &Parser{
-				args:   tt.fields.args,
 				parsed: tt.fields.parsed,
 			}
+			for _, a := range tt.fields.args {
+				if err := p.addArg(a); err != nil {
+					t.Fatal(err)
+				}
+			}
 			if err := p.parse(tt.args.args); (err != nil) != tt.wantErr {
 				t.Errorf("Parser.parse() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -234,16 +418,35 @@ func TestParser_parseArguemtns(t *testing.T) {
 					},
 				},
 			},
-			args:    args{args: &[]string{"-vv"}},
+			args:    args{args: &[]string{"-c", "/config.yml", "-1", "-"}},
 			wantErr: false,
 		},
+		{
+			name: "test parser parseArguments with unknown option",
+			fields: fields{
+				args: []*arg{
+					&arg{
+						sname:  "c",
+						size:   1,
+						unique: true,
+						value:  &conf,
+					},
+				},
+			},
+			args:    args{args: &[]string{"-vv"}},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p := &Parser{
-				args:   tt.fields.args,
 				parsed: tt.fields.parsed,
 			}
+			for _, a := range tt.fields.args {
+				if err := p.addArg(a); err != nil {
+					t.Fatal(err)
+				}
+			}
 			if err := p.parseArguemtns(tt.args.args); (err != nil) != tt.wantErr {
 				t.Errorf("Parser.parseArguemtns() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -482,6 +685,52 @@ func TestParser_BoolVar(t *testing.T) {
 	}
 }
 
+func TestParser_Count(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		def     int
+		opts    *Option
+		want    int
+		wantErr bool
+	}{
+		{name: "test count not given", args: []string{}, def: -1, want: -1},
+		{name: "test count from default", args: []string{"-v"}, def: 2, want: 3},
+		{name: "test count from default with max", args: []string{"-vv"}, def: 2, opts: &Option{Max: 3}, want: 3},
+		{name: "test count cluster", args: []string{"-vvv"}, want: 3},
+		{name: "test count mixed", args: []string{"-v", "--verbose", "-xv"}, want: 3},
+		{name: "test count with max", args: []string{"-vvvvv"}, opts: &Option{Max: 2}, want: 2},
+		{name: "test count with value", args: []string{"--verbose=3"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			got := p.Count(tt.def, "v", "verbose", "", tt.opts)
+			p.Bool(false, "x", "", "", nil)
+			err := p.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parser.Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && *got != tt.want {
+				t.Errorf("Parser.Count() = %v, want %v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_CountVar(t *testing.T) {
+	var v int
+	p := New()
+	p.CountVar(&v, 0, "v", "", "", nil)
+	if err := p.Parse([]string{"-vv"}); err != nil {
+		t.Fatalf("Parser.Parse() error = %v", err)
+	}
+	if v != 2 {
+		t.Errorf("Parser.CountVar() = %v, want 2", v)
+	}
+}
+
 func TestParser_Float(t *testing.T) {
 	type fields struct {
 		args   []*arg
@@ -837,3 +1086,260 @@ func TestParser_Help(t *testing.T) {
 		})
 	}
 }
+
+func TestParser_ParseHelp(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantHelp string
+	}{
+		{name: "test help of root parser", args: []string{"-h", "deploy"}, wantHelp: "Usage: tool [options] <command>\n"},
+		{name: "test help of command", args: []string{"deploy", "--help"}, wantHelp: "Usage: tool deploy [options]\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			p := New()
+			p.SetProg("tool")
+			p.SetOutput(buf)
+			p.Help("h", "help")
+			p.Int(0, "p", "port", "", &Option{Require: true})
+			p.AddCommand("deploy", "")
+			if err := p.Parse(tt.args); err != ErrHelp {
+				t.Errorf("Parser.Parse() error = %v, want %v", err, ErrHelp)
+			}
+			if !strings.HasPrefix(buf.String(), tt.wantHelp) {
+				t.Errorf("help = %q, want prefix %q", buf.String(), tt.wantHelp)
+			}
+		})
+	}
+}
+
+func TestParser_SetErrorHandling(t *testing.T) {
+	defer func() { exit = os.Exit }()
+
+	tests := []struct {
+		name      string
+		handling  ErrorHandling
+		args      []string
+		wantCode  int
+		wantOut   string
+		wantPanic bool
+	}{
+		{name: "test exit on help", handling: ExitOnError, args: []string{"-h"}, wantCode: 0, wantOut: "Usage:"},
+		{name: "test exit on error", handling: ExitOnError, args: []string{"--prot"}, wantCode: 2, wantOut: "unknown option --prot"},
+		{name: "test panic on error", handling: PanicOnError, args: []string{"--prot"}, wantCode: -1, wantPanic: true},
+		{name: "test continue on error", handling: ContinueOnError, args: []string{"--prot"}, wantCode: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := -1
+			exit = func(c int) { code = c }
+			defer func() {
+				if r := recover(); (r != nil) != tt.wantPanic {
+					t.Errorf("Parser.Parse() panic = %v, wantPanic %v", r, tt.wantPanic)
+				}
+			}()
+
+			buf := &bytes.Buffer{}
+			p := New()
+			p.SetOutput(buf)
+			p.SetErrorHandling(tt.handling)
+			p.Help("h", "help")
+			p.Int(0, "p", "port", "", nil)
+			err := p.Parse(tt.args)
+			if err == nil || errors.Is(err, ErrHelp) != (tt.args[0] == "-h") {
+				t.Errorf("Parser.Parse() error = %v", err)
+			}
+			if code != tt.wantCode {
+				t.Errorf("exit code = %v, want %v", code, tt.wantCode)
+			}
+			if !strings.Contains(buf.String(), tt.wantOut) {
+				t.Errorf("output = %q, want %q", buf.String(), tt.wantOut)
+			}
+		})
+	}
+}
+
+func benchmarkParse(b *testing.B, n int) {
+	argv := make([]string, 0, n*2)
+	for i := 0; i < n; i++ {
+		argv = append(argv, fmt.Sprintf("--opt%d", i), strconv.Itoa(i))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p := New()
+		for j := 0; j < n; j++ {
+			p.Int(0, "", fmt.Sprintf("opt%d", j), "", nil)
+		}
+		if err := p.Parse(argv); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParser_Parse10(b *testing.B)   { benchmarkParse(b, 10) }
+func BenchmarkParser_Parse100(b *testing.B)  { benchmarkParse(b, 100) }
+func BenchmarkParser_Parse1000(b *testing.B) { benchmarkParse(b, 1000) }
+
+func TestParser_Env(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		args      []string
+		wantPort  int
+		wantTags  []string
+		wantDebug bool
+		wantLevel int
+		wantErr   bool
+	}{
+		{name: "test default", wantPort: 80},
+		{name: "test from env", env: map[string]string{"PORT": "8080", "TAGS": "a,b", "DEBUG": "true", "LEVEL": "2"}, wantPort: 8080, wantTags: []string{"a", "b"}, wantDebug: true, wantLevel: 2},
+		{name: "test count from env capped", env: map[string]string{"LEVEL": "10"}, wantPort: 80, wantLevel: 3},
+		{name: "test command line wins", env: map[string]string{"PORT": "8080"}, args: []string{"-p", "9090"}, wantPort: 9090},
+		{name: "test bad value", env: map[string]string{"PORT": "http"}, wantErr: true},
+		{name: "test bad choice", env: map[string]string{"MODE": "fast"}, wantPort: 80, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{"PORT", "TAGS", "DEBUG", "LEVEL", "MODE"} {
+				os.Unsetenv(k)
+			}
+			for k, v := range tt.env {
+				os.Setenv(k, v)
+				defer os.Unsetenv(k)
+			}
+
+			p := New()
+			port := p.Int(80, "p", "port", "", &Option{Env: "PORT"})
+			tags := p.StringSlice(nil, "t", "tag", "", &Option{Env: "TAGS"})
+			debug := p.Bool(false, "d", "debug", "", &Option{Env: "DEBUG"})
+			level := p.Count(0, "v", "verbose", "", &Option{Env: "LEVEL", Max: 3})
+			p.String("slow", "m", "mode", "", &Option{Env: "MODE", Choices: []string{"slow"}})
+
+			err := p.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parser.Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if *port != tt.wantPort || !reflect.DeepEqual(*tags, tt.wantTags) || *debug != tt.wantDebug || *level != tt.wantLevel {
+				t.Errorf("Parser.Parse() = %v %v %v %v", *port, *tags, *debug, *level)
+			}
+		})
+	}
+}
+
+func TestParser_EnvHelp(t *testing.T) {
+	p := New()
+	p.Int(80, "p", "port", "listen port", &Option{Env: "PORT"})
+	a := p.args[0]
+
+	if got, want := p.notes(a), []string{"default: 80", "env: $PORT"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Parser.notes() = %v, want %v", got, want)
+	}
+	if got := p.helpArg(a).Env; got != "PORT" {
+		t.Errorf("Parser.helpArg().Env = %v, want PORT", got)
+	}
+	if got := p.helpText(); !strings.Contains(got, "listen port (default: 80, env: $PORT)") {
+		t.Errorf("Parser.helpText() = %q, want env note", got)
+	}
+}
+
+func TestParser_HelpOnCommands(t *testing.T) {
+	tests := []struct {
+		name      string
+		rootFirst bool
+		childLong string
+		wantPanic bool
+	}{
+		{name: "test help on root then command", rootFirst: true, childLong: "help"},
+		{name: "test help on command then root", childLong: "help"},
+		{name: "test help on command with other names", rootFirst: true, childLong: "hilfe", wantPanic: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); (r != nil) != tt.wantPanic {
+					t.Errorf("Parser.Help() panic = %v, wantPanic %v", r, tt.wantPanic)
+				}
+			}()
+
+			buf := &bytes.Buffer{}
+			p := New()
+			p.SetProg("tool")
+			p.SetOutput(buf)
+			deploy := p.AddCommand("deploy", "")
+			canary := deploy.AddCommand("canary", "")
+			if tt.rootFirst {
+				p.Help("h", "help")
+			}
+			deploy.Help("h", tt.childLong)
+			if !tt.rootFirst {
+				p.Help("h", "help")
+			}
+
+			// the abbreviation is not ambiguous although two parsers know --help
+			p.SetAbbreviations(true)
+			if err := p.Parse([]string{"deploy", "canary", "--he"}); err != ErrHelp {
+				t.Errorf("Parser.Parse() error = %v, want %v", err, ErrHelp)
+			}
+			if got := strings.Count(buf.String(), "--help"); got != 1 {
+				t.Errorf("help lists --help %d times, want once:\n%s", got, buf.String())
+			}
+			if !strings.HasPrefix(buf.String(), "Usage: tool deploy canary") || deploy.Command() != canary {
+				t.Errorf("help = %q, want help of deploy canary", buf.String())
+			}
+		})
+	}
+}
+
+func TestParser_EnvOverride(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		args         []string
+		wantJSON     bool
+		wantYAML     bool
+		wantInsecure bool
+		wantErr      bool
+	}{
+		{name: "test env exclusive alone", env: map[string]string{"PJSON": "true"}, wantJSON: true},
+		{name: "test env exclusive overridden", env: map[string]string{"PJSON": "true"}, args: []string{"--yaml"}, wantYAML: true},
+		{name: "test env conflict overridden", env: map[string]string{"PINSECURE": "true"}, args: []string{"--json", "--tls-cert", "c"}, wantJSON: true},
+		{name: "test env conflict alone", env: map[string]string{"PINSECURE": "true"}, args: []string{"--json"}, wantJSON: true, wantInsecure: true},
+		{name: "test command line exclusive still checked", env: map[string]string{"PJSON": "true"}, args: []string{"--json", "--yaml"}, wantErr: true},
+		{name: "test required exclusive missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{"PJSON", "PINSECURE"} {
+				os.Unsetenv(k)
+			}
+			for k, v := range tt.env {
+				os.Setenv(k, v)
+				defer os.Unsetenv(k)
+			}
+
+			p := New()
+			json := p.Bool(false, "", "json", "", &Option{Env: "PJSON"})
+			yaml := p.Bool(false, "", "yaml", "", nil)
+			p.MutuallyExclusive(true, "json", "yaml")
+			p.String("", "", "tls-cert", "", nil)
+			insecure := p.Bool(false, "", "insecure", "", &Option{Env: "PINSECURE", ConflictsWith: []string{"tls-cert"}})
+
+			err := p.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parser.Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if *json != tt.wantJSON || *yaml != tt.wantYAML || *insecure != tt.wantInsecure {
+				t.Errorf("Parser.Parse() = json %v yaml %v insecure %v", *json, *yaml, *insecure)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package argparse
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 )
@@ -12,82 +13,59 @@ var (
 	ErrNoArg      = errors.New("no arg")
 )
 
-func (a *arg) check(s string) (count int, err error) {
-	res := a.checkLongName(s)
-	if res > 0 {
-		return res, nil
-	}
-	return a.checkShortName(s)
-}
-
-func (a *arg) checkLongName(s string) int {
-	if a.lname != "" {
-		if len(s) > 2 && strings.HasPrefix(s, "--") && s[2] != '-' {
-			if s[2:] == a.lname {
-				return 1
-			}
+func (a *arg) name() string {
+	if a.positional {
+		if !a.unique {
+			return a.lname + "..."
 		}
+		return a.lname
 	}
-	return 0
-}
-
-func (a *arg) checkShortName(s string) (int, error) {
-	if a.sname != "" {
-		if len(s) > 1 && strings.HasPrefix(s, "-") && s[1] != '-' {
-			if s[1:] == a.sname {
-				return 1, nil
-			}
-		}
+	lname := a.lname
+	if a.negatable() {
+		lname = "[no-]" + lname
 	}
-	return 0, nil
-}
-
-func (a *arg) reduce(pos int, args *[]string) {
-	a.reduceLongName(pos, args)
-	a.reduceShortName(pos, args)
-}
-
-func (a *arg) reduceLongName(pos int, args *[]string) {
-	s := (*args)[pos]
-	if a.lname == "" {
-		return
+	if a.sname == "" {
+		return fmt.Sprintf("--%s", lname)
+	} else if a.lname == "" {
+		return fmt.Sprintf("-%s", a.sname)
+	} else {
+		return fmt.Sprintf("-%s | --%s", a.sname, lname)
 	}
+}
 
-	if res := a.checkLongName(s); res == 0 {
-		return
+// flag returns the single name the option is best known by, e.g. "--json",
+// or the name of a positional
+func (a *arg) flag() string {
+	if a.positional {
+		return a.lname
 	}
-
-	for i := pos; i <= pos+a.size; i++ {
-		(*args)[i] = ""
+	if a.lname != "" {
+		return "--" + a.lname
 	}
+	return "-" + a.sname
 }
 
-func (a *arg) reduceShortName(pos int, args *[]string) {
-	s := (*args)[pos]
-	if a.sname == "" {
-		return
-	}
-
-	if res, err := a.checkShortName(s); res == 0 || err != nil {
-		return
-	}
+// persistent reports whether subcommands accept the option too
+func (a *arg) persistent() bool {
+	return a.opts != nil && a.opts.Persistent && !a.positional
+}
 
-	for i := pos; i <= pos+a.size; i++ {
-		(*args)[i] = ""
-	}
+// negatable reports whether the option also accepts "--no-<long>"
+func (a *arg) negatable() bool {
+	return a.opts != nil && a.opts.Negatable && a.lname != ""
 }
 
-func (a *arg) name() string {
-	if a.sname == "" {
-		return fmt.Sprintf("--%s", a.lname)
-	} else if a.lname == "" {
-		return fmt.Sprintf("-%s", a.sname)
-	} else {
-		return fmt.Sprintf("-%s | --%s", a.sname, a.lname)
-	}
+// shadows reports whether a may take the names of v in a command above or
+// below it, which is the case when both are the help or both the version
+// option registered under the same names
+func (a *arg) shadows(v *arg) bool {
+	return (a.help && v.help || a.version && v.version) && a.sname == v.sname && a.lname == v.lname
 }
 
 func (a *arg) getType() string {
+	if a.counter {
+		return "count"
+	}
 	switch a.value.(type) {
 	case *string:
 		return "string"
@@ -112,15 +90,93 @@ func (a *arg) parse(args []string) error {
 	if a.unique && a.parsed {
 		return fmt.Errorf("[%s] can only parsent once\n", a.name())
 	}
+	if err := a.checkChoices(args); err != nil {
+		return err
+	}
 	return a.parseType(args)
 }
 
+func (a *arg) checkChoices(args []string) error {
+	if a.opts == nil || len(a.opts.Choices) == 0 {
+		return nil
+	}
+	for _, v := range args {
+		found := false
+		for _, c := range a.opts.Choices {
+			found = found || v == c
+		}
+		if !found {
+			return fmt.Errorf("[%s] invalid choice (%v), choose from %s", a.name(), v, strings.Join(a.opts.Choices, ", "))
+		}
+	}
+	return nil
+}
+
+// defaultText renders the default value for help, "" when there is nothing
+// worth showing
+func (a *arg) defaultText() string {
+	if a.opts != nil && a.opts.HideDefault {
+		return ""
+	}
+	if a.opts != nil && a.opts.DefaultText != "" {
+		return a.opts.DefaultText
+	}
+	if a.defaultValue == nil {
+		return ""
+	}
+
+	v := reflect.ValueOf(a.defaultValue)
+	if v.IsZero() {
+		return ""
+	}
+	if v.Kind() == reflect.Slice {
+		if v.Len() == 0 {
+			return ""
+		}
+		items := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, fmt.Sprint(v.Index(i).Interface()))
+		}
+		return strings.Join(items, ", ")
+	}
+	return fmt.Sprint(a.defaultValue)
+}
+
+// parseEnv parses the value of an environment variable, a comma separated
+// list for slices and the number of times given for counters
+func (a *arg) parseEnv(s string) error {
+	values := []string{s}
+	switch a.value.(type) {
+	case *[]string, *[]int, *[]float64:
+		values = strings.Split(s, ",")
+	case *int:
+		if a.counter {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return fmt.Errorf("[%s] bad count value (%v)", a.name(), s)
+			}
+			*a.value.(*int) = a.capCount(n)
+			a.parsed = true
+			a.fromEnv = true
+			return nil
+		}
+	}
+	if err := a.parse(values); err != nil {
+		return err
+	}
+	a.fromEnv = true
+	return nil
+}
+
 func (a *arg) parseType(args []string) error {
 	var err error
 	switch a.value.(type) {
 	case *string:
 		return a.parseString(args)
 	case *int:
+		if a.counter {
+			return a.parseCount(args)
+		}
 		return a.parseInt(args)
 	case *bool:
 		return a.parseBool(args)
@@ -185,6 +241,30 @@ func (a *arg) parseInt(args []string) error {
 	return err
 }
 
+func (a *arg) parseCount(args []string) error {
+	if len(args) > 0 {
+		return ErrArgTooMany
+	}
+
+	v := a.value.(*int)
+	if !a.parsed {
+		// counting starts from the default, like python's count action
+		*v, _ = a.defaultValue.(int)
+	}
+	*v = a.capCount(*v + 1)
+	a.parsed = true
+
+	return nil
+}
+
+// capCount clamps n to the Max of a counter
+func (a *arg) capCount(n int) int {
+	if a.opts != nil && a.opts.Max > 0 && n > a.opts.Max {
+		return a.opts.Max
+	}
+	return n
+}
+
 func (a *arg) parseIntSlice(args []string) (err error) {
 	if len(args) == 0 {
 		return ErrNoArg
@@ -204,7 +284,20 @@ func (a *arg) parseIntSlice(args []string) (err error) {
 }
 
 func (a *arg) parseBool(args []string) error {
-	*a.value.(*bool) = true
+	if len(args) > 1 {
+		return ErrArgTooMany
+	}
+
+	v := true
+	if len(args) == 1 {
+		b, err := strconv.ParseBool(args[0])
+		if err != nil {
+			return fmt.Errorf("[%s] bad bool value (%v)", a.name(), args[0])
+		}
+		v = b
+	}
+
+	*a.value.(*bool) = v
 	a.parsed = true
 	return nil
 }
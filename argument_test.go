@@ -4,358 +4,16 @@ import (
 	"testing"
 )
 
-func Test_arg_check(t *testing.T) {
-	type fields struct {
-		sname  string
-		lname  string
-		size   int
-		value  interface{}
-		unique bool
-		parsed bool
-	}
-	type args struct {
-		s string
-	}
-	tests := []struct {
-		name      string
-		fields    fields
-		args      args
-		wantCount int
-		wantErr   bool
-	}{
-		{
-			name:      "test argument check arg with short name",
-			fields:    fields{sname: "d", size: 1},
-			args:      args{s: "-d"},
-			wantCount: 1,
-			wantErr:   false,
-		},
-		{
-			name:      "test argument check arg with long name",
-			fields:    fields{lname: "dir", size: 1},
-			args:      args{s: "--dir"},
-			wantCount: 1,
-			wantErr:   false,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			a := &arg{
-				sname:  tt.fields.sname,
-				lname:  tt.fields.lname,
-				size:   tt.fields.size,
-				value:  tt.fields.value,
-				unique: tt.fields.unique,
-				parsed: tt.fields.parsed,
-			}
-			gotCount, err := a.check(tt.args.s)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("arg.check() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if gotCount != tt.wantCount {
-				t.Errorf("arg.check() = %v, want %v", gotCount, tt.wantCount)
-			}
-		})
-	}
-}
-
-func Test_arg_checkLongName(t *testing.T) {
-	type fields struct {
-		sname  string
-		lname  string
-		size   int
-		value  interface{}
-		unique bool
-		parsed bool
-	}
-	type args struct {
-		s string
-	}
-	tests := []struct {
-		name   string
-		fields fields
-		args   args
-		want   int
-	}{
-		{
-			name: "test argument check long name",
-			fields: fields{
-				lname: "dir",
-				size:  1,
-			},
-			args: args{
-				s: "--dir",
-			},
-			want: 1,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			a := &arg{
-				sname:  tt.fields.sname,
-				lname:  tt.fields.lname,
-				size:   tt.fields.size,
-				value:  tt.fields.value,
-				unique: tt.fields.unique,
-				parsed: tt.fields.parsed,
-			}
-			if got := a.checkLongName(tt.args.s); got != tt.want {
-				t.Errorf("arg.checkLongName() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
-func Test_arg_checkShortName(t *testing.T) {
-	type fields struct {
-		sname  string
-		lname  string
-		size   int
-		value  interface{}
-		unique bool
-		parsed bool
-	}
-	type args struct {
-		s string
-	}
-	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		want    int
-		wantErr bool
-	}{
-		{
-			name: "test argument check short name",
-			fields: fields{
-				sname: "d",
-				size:  1,
-			},
-			args:    args{s: "-d"},
-			want:    1,
-			wantErr: false,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			a := &arg{
-				sname:  tt.fields.sname,
-				lname:  tt.fields.lname,
-				size:   tt.fields.size,
-				value:  tt.fields.value,
-				unique: tt.fields.unique,
-				parsed: tt.fields.parsed,
-			}
-			got, err := a.checkShortName(tt.args.s)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("arg.checkShortName() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if got != tt.want {
-				t.Errorf("arg.checkShortName() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
-func Test_arg_reduce(t *testing.T) {
-	a0 := []string{"-d"}
-	a1 := []string{"--dir"}
-	type fields struct {
-		sname  string
-		lname  string
-		size   int
-		value  interface{}
-		unique bool
-		parsed bool
-	}
-	type args struct {
-		pos  int
-		args *[]string
-	}
-	tests := []struct {
-		name   string
-		fields fields
-		args   args
-	}{
-		{
-			name: "test argument reduce with short name",
-			fields: fields{
-				sname:  "d",
-				size:   0,
-				unique: true,
-			},
-			args: args{
-				pos:  0,
-				args: &a0,
-			},
-		},
-		{
-			name: "test argument reduce with long name",
-			fields: fields{
-				lname:  "dir",
-				size:   0,
-				unique: true,
-			},
-			args: args{
-				pos:  0,
-				args: &a1,
-			},
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			a := &arg{
-				sname:  tt.fields.sname,
-				lname:  tt.fields.lname,
-				size:   tt.fields.size,
-				value:  tt.fields.value,
-				unique: tt.fields.unique,
-				parsed: tt.fields.parsed,
-			}
-			a.reduce(tt.args.pos, tt.args.args)
-			if (*tt.args.args)[0] != "" {
-				t.Errorf("arg reduce failed")
-			}
-		})
-	}
-}
-
-func Test_arg_reduceLongName(t *testing.T) {
-	a0 := []string{"--dir"}
-	a1 := []string{"--file", "/config.yml"}
-	type fields struct {
-		sname  string
-		lname  string
-		size   int
-		value  interface{}
-		unique bool
-		parsed bool
-	}
-	type args struct {
-		pos  int
-		args *[]string
-	}
-	tests := []struct {
-		name   string
-		fields fields
-		args   args
-	}{
-		{
-			name: "test argument reduce long name 1",
-			fields: fields{
-				lname: "dir",
-				size:  0,
-			},
-			args: args{
-				pos:  0,
-				args: &a0,
-			},
-		},
-		{
-			name: "test argument redue long name 2",
-			fields: fields{
-				lname: "file",
-				size:  1,
-			},
-			args: args{
-				pos:  0,
-				args: &a1,
-			},
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			a := &arg{
-				sname:  tt.fields.sname,
-				lname:  tt.fields.lname,
-				size:   tt.fields.size,
-				value:  tt.fields.value,
-				unique: tt.fields.unique,
-				parsed: tt.fields.parsed,
-			}
-			a.reduceLongName(tt.args.pos, tt.args.args)
-			for i := tt.args.pos; i < tt.args.pos+tt.fields.size; i++ {
-				if (*tt.args.args)[i] != "" {
-					t.Errorf("arg reduce fail")
-				}
-			}
-		})
-	}
-}
-
-func Test_arg_reduceShortName(t *testing.T) {
-	a0 := []string{"-d"}
-	a1 := []string{"-f", "/config.yml"}
-	type fields struct {
-		sname  string
-		lname  string
-		size   int
-		value  interface{}
-		unique bool
-		parsed bool
-	}
-	type args struct {
-		pos  int
-		args *[]string
-	}
-	tests := []struct {
-		name   string
-		fields fields
-		args   args
-	}{
-		{
-			name: "test argument reduce short name 1",
-			fields: fields{
-				sname: "d",
-				size:  0,
-			},
-			args: args{
-				pos:  0,
-				args: &a0,
-			},
-		},
-		{
-			name: "test argument reduce short name 2",
-			fields: fields{
-				sname: "f",
-				size:  1,
-			},
-			args: args{
-				pos:  0,
-				args: &a1,
-			},
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			a := &arg{
-				sname:  tt.fields.sname,
-				lname:  tt.fields.lname,
-				size:   tt.fields.size,
-				value:  tt.fields.value,
-				unique: tt.fields.unique,
-				parsed: tt.fields.parsed,
-			}
-			a.reduceShortName(tt.args.pos, tt.args.args)
-			for i := tt.args.pos; i < tt.args.pos+tt.fields.size; i++ {
-				if (*tt.args.args)[i] != "" {
-					t.Errorf("arg reduce fail")
-				}
-			}
-		})
-	}
-}
-
 func Test_arg_name(t *testing.T) {
 	type fields struct {
-		sname  string
-		lname  string
-		size   int
-		value  interface{}
-		unique bool
-		parsed bool
+		sname      string
+		lname      string
+		size       int
+		value      interface{}
+		unique     bool
+		parsed     bool
+		positional bool
+		opts       *Option
 	}
 	tests := []struct {
 		name   string
@@ -384,16 +42,36 @@ func Test_arg_name(t *testing.T) {
 			},
 			want: "-d | --dir",
 		},
+		{
+			name: "test argument get name of negatable",
+			fields: fields{
+				sname: "c",
+				lname: "cache",
+				value: new(bool),
+				opts:  &Option{Negatable: true},
+			},
+			want: "-c | --[no-]cache",
+		},
+		{
+			name: "test argument get name of variadic positional",
+			fields: fields{
+				lname:      "FILES",
+				positional: true,
+			},
+			want: "FILES...",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			a := &arg{
-				sname:  tt.fields.sname,
-				lname:  tt.fields.lname,
-				size:   tt.fields.size,
-				value:  tt.fields.value,
-				unique: tt.fields.unique,
-				parsed: tt.fields.parsed,
+				sname:      tt.fields.sname,
+				lname:      tt.fields.lname,
+				size:       tt.fields.size,
+				value:      tt.fields.value,
+				unique:     tt.fields.unique,
+				parsed:     tt.fields.parsed,
+				positional: tt.fields.positional,
+				opts:       tt.fields.opts,
 			}
 			if got := a.name(); got != tt.want {
 				t.Errorf("arg.name() = %v, want %v", got, tt.want)
@@ -609,6 +287,49 @@ func Test_arg_parseInt(t *testing.T) {
 	}
 }
 
+func Test_arg_parseCount(t *testing.T) {
+	var n int
+	tests := []struct {
+		name    string
+		a       *arg
+		times   int
+		want    int
+		wantErr bool
+	}{
+		{
+			name:  "test argument parseCount",
+			a:     &arg{sname: "v", value: &n, counter: true},
+			times: 3,
+			want:  3,
+		},
+		{
+			name:  "test argument parseCount with max",
+			a:     &arg{sname: "v", value: &n, counter: true, opts: &Option{Max: 1}},
+			times: 3,
+			want:  1,
+		},
+		{
+			name:  "test argument parseCount from default",
+			a:     &arg{sname: "v", value: &n, defaultValue: 2, counter: true},
+			times: 2,
+			want:  4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n = 10
+			for i := 0; i < tt.times; i++ {
+				if err := tt.a.parseCount(nil); (err != nil) != tt.wantErr {
+					t.Errorf("arg.parseCount() error = %v, wantErr %v", err, tt.wantErr)
+				}
+			}
+			if n != tt.want {
+				t.Errorf("arg.parseCount() value = %v, want %v", n, tt.want)
+			}
+		})
+	}
+}
+
 func Test_arg_parseBool(t *testing.T) {
 	var b bool
 	type fields struct {
@@ -626,6 +347,7 @@ func Test_arg_parseBool(t *testing.T) {
 		name    string
 		fields  fields
 		args    args
+		want    bool
 		wantErr bool
 	}{
 		{
@@ -635,8 +357,28 @@ func Test_arg_parseBool(t *testing.T) {
 				unique: true,
 			},
 			args:    args{},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "test argument parseBool with explicit false",
+			fields: fields{
+				value:  &b,
+				unique: true,
+			},
+			args:    args{args: []string{"0"}},
+			want:    false,
 			wantErr: false,
 		},
+		{
+			name: "test argument parseBool with bad value",
+			fields: fields{
+				value:  &b,
+				unique: true,
+			},
+			args:    args{args: []string{"maybe"}},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -651,6 +393,9 @@ func Test_arg_parseBool(t *testing.T) {
 			if err := a.parseBool(tt.args.args); (err != nil) != tt.wantErr {
 				t.Errorf("arg.parseBool() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if !tt.wantErr && b != tt.want {
+				t.Errorf("arg.parseBool() value = %v, want %v", b, tt.want)
+			}
 		})
 	}
 }
@@ -913,3 +658,25 @@ func Test_arg_getType(t *testing.T) {
 		})
 	}
 }
+
+func Test_arg_checkChoices(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    *Option
+		args    []string
+		wantErr bool
+	}{
+		{name: "test check choices without choices", args: []string{"xml"}},
+		{name: "test check choices valid", opts: &Option{Choices: []string{"json", "yaml"}}, args: []string{"yaml"}},
+		{name: "test check choices invalid", opts: &Option{Choices: []string{"json", "yaml"}}, args: []string{"xml"}, wantErr: true},
+		{name: "test check choices one of many invalid", opts: &Option{Choices: []string{"1", "2"}}, args: []string{"1", "3"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &arg{lname: "format", opts: tt.opts}
+			if err := a.checkChoices(tt.args); (err != nil) != tt.wantErr {
+				t.Errorf("arg.checkChoices() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+package argparse
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrMissingCommand is returned by Run when a parser with commands is run
+// without one and has no handler of its own
+var ErrMissingCommand = errors.New("missing command")
+
+// Handler is called by Run with the parser of the selected command
+type Handler func(p *Parser) error
+
+// AddCommand registers a subcommand and returns its parser. The first
+// argument on the command line that is not an option selects it, everything
+// after it is parsed by the returned parser.
+func (p *Parser) AddCommand(name, description string) *Parser {
+	if name == "" || strings.HasPrefix(name, "-") {
+		panic(fmt.Errorf("unable to add command: bad name %q", name))
+	}
+	if p.findCommand(name) != nil {
+		panic(fmt.Errorf("unable to add command: name dup: %s", name))
+	}
+	for _, v := range p.args {
+		if v.positional {
+			panic(errors.New("unable to add command: parser has positionals"))
+		}
+	}
+
+	c := New()
+	c.name = name
+	c.description = description
+	c.parent = p
+
+	p.commands = append(p.commands, c)
+
+	return c
+}
+
+// AddAlias registers other names the command can be selected with
+func (p *Parser) AddAlias(names ...string) {
+	if p.parent == nil {
+		panic(errors.New("unable to add alias: not a command"))
+	}
+	for _, name := range names {
+		if name == "" || strings.HasPrefix(name, "-") {
+			panic(fmt.Errorf("unable to add alias: bad name %q", name))
+		}
+		if p.parent.findCommand(name) != nil {
+			panic(fmt.Errorf("unable to add alias: name dup: %s", name))
+		}
+		p.aliases = append(p.aliases, name)
+	}
+}
+
+// SetHidden keeps the command out of help and suggestions, it can still be
+// run
+func (p *Parser) SetHidden(hidden bool) {
+	p.hidden = hidden
+}
+
+// SetDeprecated marks the command as deprecated. It still runs but prints
+// message, e.g. "use remove instead", to stderr and is left out of help.
+func (p *Parser) SetDeprecated(message string) {
+	p.deprecated = message
+}
+
+// Handle sets the function Run calls when this command is selected
+func (p *Parser) Handle(h Handler) {
+	p.handler = h
+}
+
+// Command returns the parser of the selected subcommand, or nil when none was
+// given
+func (p *Parser) Command() *Parser {
+	return p.command
+}
+
+// Name returns the name of a command parser, the root parser has none
+func (p *Parser) Name() string {
+	return p.name
+}
+
+// Run parses a and calls the handler of the most deeply selected command
+func (p *Parser) Run(a []string) error {
+	if err := p.Parse(a); err != nil {
+		return err
+	}
+
+	c := p
+	for c.command != nil {
+		c = c.command
+	}
+
+	if c.plugin != "" {
+		return c.runPlugin()
+	}
+	if c.handler != nil {
+		return c.handler(c)
+	}
+	if len(c.commands) > 0 {
+		return ErrMissingCommand
+	}
+	return nil
+}
+
+func (p *Parser) findCommand(name string) *Parser {
+	for _, c := range p.commands {
+		if c.name == name {
+			return c
+		}
+		for _, v := range c.aliases {
+			if v == name {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// visible reports whether the command shows up in help and suggestions
+func (p *Parser) visible() bool {
+	return !p.hidden && p.deprecated == ""
+}
+
+// visibleCommands returns the commands listed in help
+func (p *Parser) visibleCommands() []*Parser {
+	res := make([]*Parser, 0, len(p.commands))
+	for _, c := range p.commands {
+		if c.visible() {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// warnDeprecated tells the user a deprecated command was selected
+func (p *Parser) warnDeprecated(w io.Writer) {
+	if p.deprecated == "" {
+		return
+	}
+	fmt.Fprintf(w, "Command %q is deprecated, %s\n", p.name, p.deprecated)
+}
+
+// root returns the top of the command tree, which holds the settings shared
+// by all commands
+func (p *Parser) root() *Parser {
+	r := p
+	for r.parent != nil {
+		r = r.parent
+	}
+	return r
+}
+
+// path returns the command names from the root down to p
+func (p *Parser) path() string {
+	names := make([]string, 0)
+	for c := p; c != nil; c = c.parent {
+		if c.name != "" {
+			names = append([]string{c.name}, names...)
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+// suggestCommands returns the command names closest to the unknown name s
+func (p *Parser) suggestCommands(s string) []string {
+	if p.root().noSuggest {
+		return nil
+	}
+
+	candidates := make([]string, 0, len(p.commands))
+	for _, c := range p.visibleCommands() {
+		candidates = append(candidates, c.name)
+		candidates = append(candidates, c.aliases...)
+	}
+
+	return p.suggest(s, candidates, func(c string) string { return c })
+}
+
+// inherited returns the persistent args of all ancestors, nearest first
+func (p *Parser) inherited() []*arg {
+	res := make([]*arg, 0)
+	for c := p.parent; c != nil; c = c.parent {
+		for _, v := range c.args {
+			if v.persistent() && !p.shadowed(v) {
+				res = append(res, v)
+			}
+		}
+	}
+	return res
+}
+
+// longArg resolves a long name, falling back to persistent ancestor args
+func (p *Parser) longArg(name string) *arg {
+	if a := p.lnames[name]; a != nil {
+		return a
+	}
+	for c := p.parent; c != nil; c = c.parent {
+		if a := c.lnames[name]; a != nil && a.persistent() {
+			return a
+		}
+	}
+	return nil
+}
+
+// shortArg resolves a short name, falling back to persistent ancestor args
+func (p *Parser) shortArg(name string) *arg {
+	if a := p.snames[name]; a != nil {
+		return a
+	}
+	for c := p.parent; c != nil; c = c.parent {
+		if a := c.snames[name]; a != nil && a.persistent() {
+			return a
+		}
+	}
+	return nil
+}
+
+// longNames returns every long name accepted by p, inherited ones included
+func (p *Parser) longNames() []string {
+	res := make([]string, 0, len(p.lnames))
+	for k := range p.lnames {
+		res = append(res, k)
+	}
+	for c := p.parent; c != nil; c = c.parent {
+		for k, a := range c.lnames {
+			// names taken again further down are listed once
+			if a.persistent() && p.longArg(k) == a {
+				res = append(res, k)
+			}
+		}
+	}
+	return res
+}
+
+// shortNames returns every short name accepted by p, inherited ones included
+func (p *Parser) shortNames() []string {
+	res := make([]string, 0, len(p.snames))
+	for k := range p.snames {
+		res = append(res, k)
+	}
+	for c := p.parent; c != nil; c = c.parent {
+		for k, a := range c.snames {
+			// names taken again further down are listed once
+			if a.persistent() && p.shortArg(k) == a {
+				res = append(res, k)
+			}
+		}
+	}
+	return res
+}
+
+// checkTree makes sure the names of a do not clash with options it would
+// share with the rest of the command tree: persistent options of ancestors,
+// and every option of descendants when a is persistent itself
+func (p *Parser) checkTree(a *arg) error {
+	for c := p.parent; c != nil; c = c.parent {
+		if v := c.clash(a); v != nil && v.persistent() && !a.shadows(v) {
+			return fmt.Errorf("option name dup: %s inherited from %s", v.name(), c.commandName())
+		}
+	}
+
+	if !a.persistent() {
+		return nil
+	}
+	var walk func(c *Parser) error
+	walk = func(c *Parser) error {
+		for _, sub := range c.commands {
+			if v := sub.clash(a); v != nil && !a.shadows(v) {
+				return fmt.Errorf("option name dup: %s in command %s", v.name(), sub.path())
+			}
+			if err := walk(sub); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(p)
+}
+
+// clash returns the arg of p sharing a name with a, if any
+// shadowed reports whether the inherited option v is hidden from p by an
+// option of the same name registered closer to p
+func (p *Parser) shadowed(v *arg) bool {
+	return (v.lname != "" && p.longArg(v.lname) != v) || (v.sname != "" && p.shortArg(v.sname) != v)
+}
+
+func (p *Parser) clash(a *arg) *arg {
+	if v := p.snames[a.sname]; a.sname != "" && v != nil {
+		return v
+	}
+	if v := p.lnames[a.lname]; a.lname != "" && v != nil {
+		return v
+	}
+	if v := p.lnames["no-"+a.lname]; a.negatable() && v != nil {
+		return v
+	}
+	return nil
+}
+
+// commandName names p in messages, the root parser being "root"
+func (p *Parser) commandName() string {
+	if p.parent == nil {
+		return "root"
+	}
+	return p.path()
+}
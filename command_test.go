@@ -0,0 +1,413 @@
+package argparse
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParser_AddCommand(t *testing.T) {
+	tests := []struct {
+		name      string
+		register  func(p *Parser)
+		wantPanic bool
+	}{
+		{
+			name: "test add commands",
+			register: func(p *Parser) {
+				p.AddCommand("deploy", "")
+				p.AddCommand("rollback", "")
+			},
+		},
+		{
+			name: "test add command with duplicate name",
+			register: func(p *Parser) {
+				p.AddCommand("deploy", "")
+				p.AddCommand("deploy", "")
+			},
+			wantPanic: true,
+		},
+		{
+			name: "test add command with dashed name",
+			register: func(p *Parser) {
+				p.AddCommand("-deploy", "")
+			},
+			wantPanic: true,
+		},
+		{
+			name: "test add command to parser with positionals",
+			register: func(p *Parser) {
+				p.PositionalString("", "SRC", "", nil)
+				p.AddCommand("deploy", "")
+			},
+			wantPanic: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); (r != nil) != tt.wantPanic {
+					t.Errorf("Parser.AddCommand() panic = %v, wantPanic %v", r, tt.wantPanic)
+				}
+			}()
+			tt.register(New())
+		})
+	}
+}
+
+func TestParser_AddCommandHelp(t *testing.T) {
+	p := New()
+	before := p.AddCommand("deploy", "")
+	p.Help("h", "help")
+	after := p.AddCommand("rollback", "")
+	for _, c := range []*Parser{before, after} {
+		if a := c.shortArg("h"); a == nil || !a.help || c.longArg("help") != a {
+			t.Errorf("command %s help = %v, want -h | --help", c.Name(), a)
+		}
+	}
+}
+
+func TestParser_ParseCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantCommand string
+		wantEnv     string
+		wantTo      string
+		wantArgs    []string
+		wantErr     bool
+	}{
+		{
+			name:        "test parse command with options",
+			args:        []string{"-v", "deploy", "--env", "prod", "extra"},
+			wantCommand: "deploy",
+			wantEnv:     "prod",
+			wantArgs:    []string{"extra"},
+		},
+		{
+			name:        "test parse other command",
+			args:        []string{"rollback", "--to", "v3"},
+			wantCommand: "rollback",
+			wantTo:      "v3",
+			wantArgs:    []string{},
+		},
+		{
+			name: "test parse without command",
+			args: []string{"-v"},
+		},
+		{
+			name:    "test parse command option before command",
+			args:    []string{"--env", "prod", "deploy"},
+			wantErr: true,
+		},
+		{
+			name:    "test parse unknown command",
+			args:    []string{"deplyo"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			p.Bool(false, "v", "verbose", "", nil)
+			deploy := p.AddCommand("deploy", "deploy the service")
+			env := deploy.String("dev", "e", "env", "", nil)
+			rollback := p.AddCommand("rollback", "roll back to a release")
+			to := rollback.String("", "", "to", "", nil)
+
+			err := p.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parser.Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.wantCommand == "" {
+				if p.Command() != nil {
+					t.Errorf("Parser.Command() = %v, want nil", p.Command().Name())
+				}
+				return
+			}
+			if got := p.Command(); got == nil || got.Name() != tt.wantCommand {
+				t.Fatalf("Parser.Command() = %v, want %v", got, tt.wantCommand)
+			}
+			if tt.wantCommand == "deploy" && *env != tt.wantEnv {
+				t.Errorf("env = %v, want %v", *env, tt.wantEnv)
+			}
+			if *to != tt.wantTo {
+				t.Errorf("to = %v, want %v", *to, tt.wantTo)
+			}
+			if got := p.Command().Args(); !reflect.DeepEqual(got, tt.wantArgs) {
+				t.Errorf("Command().Args() = %v, want %v", got, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestUnknownCommandError_Error(t *testing.T) {
+	p := New()
+	p.AddCommand("deploy", "")
+	p.AddCommand("rollback", "")
+	err := p.Parse([]string{"deplyo"})
+	want := "unknown command deplyo, did you mean deploy?"
+	if err == nil || err.Error() != want {
+		t.Errorf("Parser.Parse() error = %v, want %v", err, want)
+	}
+}
+
+func TestParser_Run(t *testing.T) {
+	errDeploy := errors.New("deploy failed")
+	tests := []struct {
+		name    string
+		args    []string
+		wantRan string
+		wantErr error
+	}{
+		{name: "test run nested command", args: []string{"deploy", "canary"}, wantRan: "canary"},
+		{name: "test run command handler error", args: []string{"deploy"}, wantRan: "deploy", wantErr: errDeploy},
+		{name: "test run root handler", args: []string{}, wantRan: "root"},
+		{name: "test run command without handler", args: []string{"status"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := ""
+			p := New()
+			p.Handle(func(p *Parser) error {
+				ran = "root"
+				return nil
+			})
+			deploy := p.AddCommand("deploy", "")
+			deploy.Handle(func(p *Parser) error {
+				ran = p.Name()
+				return errDeploy
+			})
+			canary := deploy.AddCommand("canary", "")
+			canary.Handle(func(p *Parser) error {
+				ran = p.Name()
+				return nil
+			})
+			p.AddCommand("status", "")
+
+			if err := p.Run(tt.args); err != tt.wantErr {
+				t.Errorf("Parser.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if ran != tt.wantRan {
+				t.Errorf("Parser.Run() ran = %v, want %v", ran, tt.wantRan)
+			}
+		})
+	}
+}
+
+func TestParser_RunMissingCommand(t *testing.T) {
+	p := New()
+	p.AddCommand("deploy", "")
+	if err := p.Run([]string{}); err != ErrMissingCommand {
+		t.Errorf("Parser.Run() error = %v, want %v", err, ErrMissingCommand)
+	}
+}
+
+func TestParser_path(t *testing.T) {
+	p := New()
+	c := p.AddCommand("deploy", "").AddCommand("canary", "")
+	if got := c.path(); got != "deploy canary" {
+		t.Errorf("Parser.path() = %v, want %v", got, "deploy canary")
+	}
+	if got := c.usage(); got != "" {
+		t.Errorf("Parser.usage() = %v, want empty", got)
+	}
+}
+
+func TestParser_ParsePersistent(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantVerbose bool
+		wantConfig  string
+		wantErr     bool
+	}{
+		{name: "test persistent option before command", args: []string{"--verbose", "deploy", "canary"}, wantVerbose: true, wantConfig: "default.yml"},
+		{name: "test persistent option after command", args: []string{"deploy", "-v"}, wantVerbose: true, wantConfig: "default.yml"},
+		{name: "test persistent option in nested command", args: []string{"deploy", "canary", "-vc", "a.yml"}, wantVerbose: true, wantConfig: "a.yml"},
+		{name: "test persistent option given twice", args: []string{"-v", "deploy", "-v"}, wantErr: true},
+		{name: "test local option not inherited", args: []string{"deploy", "--local"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			verbose := p.Bool(false, "v", "verbose", "", &Option{Persistent: true})
+			config := p.String("default.yml", "c", "config", "", &Option{Persistent: true})
+			p.Bool(false, "", "local", "", nil)
+			p.AddCommand("deploy", "").AddCommand("canary", "")
+			err := p.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parser.Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && (*verbose != tt.wantVerbose || *config != tt.wantConfig) {
+				t.Errorf("options = (%v, %v), want (%v, %v)", *verbose, *config, tt.wantVerbose, tt.wantConfig)
+			}
+		})
+	}
+}
+
+func TestParser_checkTree(t *testing.T) {
+	tests := []struct {
+		name      string
+		register  func(p *Parser)
+		wantPanic bool
+	}{
+		{
+			name: "test command option shadowing persistent option",
+			register: func(p *Parser) {
+				p.Bool(false, "v", "verbose", "", &Option{Persistent: true})
+				p.AddCommand("deploy", "").AddCommand("canary", "").Bool(false, "v", "", "", nil)
+			},
+			wantPanic: true,
+		},
+		{
+			name: "test persistent option shadowing command option",
+			register: func(p *Parser) {
+				p.AddCommand("deploy", "").AddCommand("canary", "").Bool(false, "", "verbose", "", nil)
+				p.Bool(false, "v", "verbose", "", &Option{Persistent: true})
+			},
+			wantPanic: true,
+		},
+		{
+			name: "test command option sharing local option name",
+			register: func(p *Parser) {
+				p.Bool(false, "v", "verbose", "", nil)
+				p.AddCommand("deploy", "").Bool(false, "v", "verbose", "", nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); (r != nil) != tt.wantPanic {
+					t.Errorf("Parser.checkTree() panic = %v, wantPanic %v", r, tt.wantPanic)
+				}
+			}()
+			tt.register(New())
+		})
+	}
+}
+
+func TestParser_inherited(t *testing.T) {
+	p := New()
+	p.Help("h", "help")
+	p.Bool(false, "", "local", "", nil)
+	deploy := p.AddCommand("deploy", "")
+	deploy.String("", "e", "env", "", &Option{Persistent: true})
+	canary := deploy.AddCommand("canary", "")
+
+	got := make([]string, 0)
+	for _, a := range canary.inherited() {
+		got = append(got, a.name())
+	}
+	want := []string{"-e | --env", "-h | --help"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parser.inherited() = %v, want %v", got, want)
+	}
+}
+
+func TestParser_AddAlias(t *testing.T) {
+	tests := []struct {
+		name      string
+		register  func(p *Parser)
+		wantPanic bool
+	}{
+		{
+			name: "test add aliases",
+			register: func(p *Parser) {
+				p.AddCommand("remove", "").AddAlias("rm", "del")
+			},
+		},
+		{
+			name: "test add alias clashing with command",
+			register: func(p *Parser) {
+				p.AddCommand("list", "")
+				p.AddCommand("remove", "").AddAlias("list")
+			},
+			wantPanic: true,
+		},
+		{
+			name: "test add command clashing with alias",
+			register: func(p *Parser) {
+				p.AddCommand("remove", "").AddAlias("rm")
+				p.AddCommand("rm", "")
+			},
+			wantPanic: true,
+		},
+		{
+			name: "test add alias to root parser",
+			register: func(p *Parser) {
+				p.AddAlias("rm")
+			},
+			wantPanic: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); (r != nil) != tt.wantPanic {
+					t.Errorf("Parser.AddAlias() panic = %v, wantPanic %v", r, tt.wantPanic)
+				}
+			}()
+			tt.register(New())
+		})
+	}
+}
+
+func TestParser_ParseCommandAlias(t *testing.T) {
+	for _, name := range []string{"remove", "rm", "purge"} {
+		t.Run(name, func(t *testing.T) {
+			p := New()
+			remove := p.AddCommand("remove", "")
+			remove.AddAlias("rm")
+			purge := p.AddCommand("purge", "")
+			purge.SetHidden(true)
+			if err := p.Parse([]string{name}); err != nil {
+				t.Fatalf("Parser.Parse() error = %v", err)
+			}
+			want := "remove"
+			if name == "purge" {
+				want = "purge"
+			}
+			if got := p.Command().Name(); got != want {
+				t.Errorf("Parser.Command() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestParser_visibleCommands(t *testing.T) {
+	p := New()
+	p.AddCommand("remove", "")
+	p.AddCommand("purge", "").SetHidden(true)
+	p.AddCommand("delete", "").SetDeprecated("use remove instead")
+
+	got := make([]string, 0)
+	for _, c := range p.visibleCommands() {
+		got = append(got, c.Name())
+	}
+	if want := []string{"remove"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Parser.visibleCommands() = %v, want %v", got, want)
+	}
+	if got := p.suggestCommands("purg"); len(got) != 0 {
+		t.Errorf("Parser.suggestCommands() = %v, want none", got)
+	}
+}
+
+func TestParser_warnDeprecated(t *testing.T) {
+	p := New()
+	c := p.AddCommand("delete", "")
+	c.SetDeprecated("use remove instead")
+	buf := &bytes.Buffer{}
+	c.warnDeprecated(buf)
+	want := "Command \"delete\" is deprecated, use remove instead\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Parser.warnDeprecated() = %q, want %q", got, want)
+	}
+}
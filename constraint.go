@@ -0,0 +1,183 @@
+package argparse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// constraint kinds, as used in ConstraintError.Rule
+const (
+	RuleRequires       = "requires"
+	RuleConflictsWith  = "conflicts with"
+	RuleRequiredIf     = "required if"
+	RuleRequiredUnless = "required unless"
+)
+
+// resolveArg finds an option of p or an inherited one by its long or short
+// name, without dashes, or a positional of p by its name
+func (p *Parser) resolveArg(name string) (*arg, error) {
+	if a := p.longArg(name); a != nil {
+		return a, nil
+	}
+	if a := p.shortArg(name); a != nil {
+		return a, nil
+	}
+	for _, v := range p.positionals() {
+		if v.lname == name {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown option %s", name)
+}
+
+// resolveArgs finds the options of names, checkConstraintNames made sure
+// they all exist
+func (p *Parser) resolveArgs(names []string) []*arg {
+	res := make([]*arg, 0, len(names))
+	for _, name := range names {
+		if a, err := p.resolveArg(name); err == nil {
+			res = append(res, a)
+		}
+	}
+	return res
+}
+
+// checkConstraintNames panics when a constraint of p or of a command below
+// it names an unknown option. Like in MutuallyExclusive that is a mistake of
+// the program, it is caught before any argument is looked at since
+// constraints may name options registered after them.
+func (p *Parser) checkConstraintNames() {
+	for _, v := range p.args {
+		if v.opts == nil {
+			continue
+		}
+		for _, names := range [][]string{v.opts.Requires, v.opts.ConflictsWith, v.opts.RequiredIf, v.opts.RequiredUnless} {
+			for _, name := range names {
+				if _, err := p.resolveArg(name); err != nil {
+					panic(fmt.Errorf("unable to add constraint to %s: %s", v.flag(), err))
+				}
+			}
+		}
+	}
+	for _, c := range p.commands {
+		c.checkConstraintNames()
+	}
+}
+
+// checkConstraints evaluates the Requires, ConflictsWith, RequiredIf and
+// RequiredUnless rules of every option once parsing is done
+func (p *Parser) checkConstraints() error {
+	for _, v := range p.args {
+		if v.opts == nil {
+			continue
+		}
+
+		requires := p.resolveArgs(v.opts.Requires)
+		for _, o := range requires {
+			if v.parsed && !o.parsed {
+				return &ConstraintError{Option: v.flag(), Other: o.flag(), Rule: RuleRequires}
+			}
+		}
+
+		conflicts := p.resolveArgs(v.opts.ConflictsWith)
+		for _, o := range conflicts {
+			// values from the environment give way, see parseEnv
+			if v.parsed && o.parsed && !v.fromEnv && !o.fromEnv {
+				return &ConstraintError{Option: v.flag(), Other: o.flag(), Rule: RuleConflictsWith}
+			}
+		}
+
+		requiredIf := p.resolveArgs(v.opts.RequiredIf)
+		for _, o := range requiredIf {
+			if o.parsed && !v.parsed {
+				return &ConstraintError{Option: v.flag(), Other: o.flag(), Rule: RuleRequiredIf}
+			}
+		}
+
+		unless := p.resolveArgs(v.opts.RequiredUnless)
+		if len(unless) == 0 || v.parsed {
+			continue
+		}
+		given := false
+		flags := make([]string, 0, len(unless))
+		for _, o := range unless {
+			given = given || o.parsed
+			flags = append(flags, o.flag())
+		}
+		if !given {
+			return &ConstraintError{Option: v.flag(), Other: strings.Join(flags, " or "), Rule: RuleRequiredUnless}
+		}
+	}
+
+	return nil
+}
+
+// overridden reports whether an option a conflicts with, through
+// ConflictsWith or a MutuallyExclusive group, was given on the command line
+func (p *Parser) overridden(a *arg) bool {
+	given := func(o *arg) bool {
+		return o != a && o.parsed && !o.fromEnv
+	}
+
+	for _, g := range p.exclusive {
+		in := false
+		for _, v := range g.args {
+			in = in || v == a
+		}
+		for _, v := range g.args {
+			if in && given(v) {
+				return true
+			}
+		}
+	}
+
+	for _, o := range p.resolveArgs(a.opts.ConflictsWith) {
+		if given(o) {
+			return true
+		}
+	}
+	for _, v := range p.args {
+		if v.opts == nil || !given(v) {
+			continue
+		}
+		for _, o := range p.resolveArgs(v.opts.ConflictsWith) {
+			if o == a {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// constraintNotes describes the constraints of a for help text
+func (p *Parser) constraintNotes(a *arg) []string {
+	if a.opts == nil {
+		return nil
+	}
+
+	notes := make([]string, 0)
+	rules := []struct {
+		rule  string
+		names []string
+	}{
+		{RuleRequires, a.opts.Requires},
+		{RuleConflictsWith, a.opts.ConflictsWith},
+		{RuleRequiredIf, a.opts.RequiredIf},
+		{RuleRequiredUnless, a.opts.RequiredUnless},
+	}
+	for _, r := range rules {
+		if len(r.names) == 0 {
+			continue
+		}
+		flags := make([]string, 0, len(r.names))
+		for _, name := range r.names {
+			if o, err := p.resolveArg(name); err == nil {
+				flags = append(flags, o.flag())
+			} else {
+				flags = append(flags, name)
+			}
+		}
+		notes = append(notes, fmt.Sprintf("%s %s", r.rule, strings.Join(flags, " or ")))
+	}
+	return notes
+}
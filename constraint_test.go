@@ -0,0 +1,121 @@
+package argparse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParser_checkConstraints(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "test constraints nothing given", args: []string{"--token", "t"}},
+		{name: "test constraints requires met", args: []string{"--tls-key", "k", "--tls-cert", "c", "--token", "t"}},
+		{name: "test constraints requires broken", args: []string{"--tls-key", "k", "--token", "t"}, wantErr: "--tls-key requires --tls-cert"},
+		{name: "test constraints conflicts broken", args: []string{"--insecure", "--tls-cert", "c", "--token", "t"}, wantErr: "--insecure conflicts with --tls-cert"},
+		{name: "test constraints required if met", args: []string{"-u", "me", "--password", "p"}},
+		{name: "test constraints required if broken", args: []string{"-u", "me", "--token", "t"}, wantErr: "--password is required when --user is given"},
+		{name: "test constraints required unless broken", args: []string{"--anon"}, wantErr: "--token is required unless --user or --ticket is given"},
+		{name: "test constraints required unless met", args: []string{"--ticket", "t"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			p.String("", "", "tls-cert", "", nil)
+			p.String("", "", "tls-key", "", &Option{Requires: []string{"tls-cert"}})
+			p.Bool(false, "", "insecure", "", &Option{ConflictsWith: []string{"tls-cert"}})
+			p.String("", "u", "user", "", nil)
+			p.String("", "", "password", "", &Option{RequiredIf: []string{"u"}})
+			p.String("", "", "token", "", &Option{RequiredUnless: []string{"user", "ticket"}})
+			p.String("", "", "ticket", "", nil)
+			p.Bool(false, "", "anon", "", nil)
+			err := p.Parse(tt.args)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Parser.Parse() error = %v", err)
+				}
+				return
+			}
+			if _, ok := err.(*ConstraintError); !ok || err.Error() != tt.wantErr {
+				t.Errorf("Parser.Parse() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParser_checkConstraintNames(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      *Option
+		wantPanic bool
+	}{
+		{name: "test constraint names known", opts: &Option{Requires: []string{"tls-cert"}, ConflictsWith: []string{"v"}}},
+		{name: "test constraint name unknown", opts: &Option{Requires: []string{"tls-crt"}}, wantPanic: true},
+		{name: "test constraint name unknown in command", opts: &Option{RequiredUnless: []string{"user"}}, wantPanic: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); (r != nil) != tt.wantPanic {
+					t.Errorf("Parser.Parse() panic = %v, wantPanic %v", r, tt.wantPanic)
+				}
+			}()
+
+			p := New()
+			p.Bool(false, "v", "", "", &Option{Persistent: true})
+			deploy := p.AddCommand("deploy", "")
+			deploy.String("", "", "tls-key", "", tt.opts)
+			deploy.String("", "", "tls-cert", "", nil)
+			// the command is not selected, its names are checked all the same
+			p.Parse([]string{})
+		})
+	}
+}
+
+func TestParser_constraintNotes(t *testing.T) {
+	p := New()
+	p.String("", "c", "", "", nil)
+	p.String("", "u", "user", "", nil)
+	a := &arg{lname: "password", opts: &Option{
+		Requires:       []string{"c"},
+		RequiredUnless: []string{"user", "token"},
+	}}
+
+	want := []string{"requires -c", "required unless --user or token"}
+	if got := p.constraintNotes(a); !reflect.DeepEqual(got, want) {
+		t.Errorf("Parser.constraintNotes() = %v, want %v", got, want)
+	}
+}
+
+func TestParser_checkConstraintsPositional(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "test constraints positional not given", args: []string{}},
+		{name: "test constraints positional met", args: []string{"--dest", "b", "a"}},
+		{name: "test constraints positional broken", args: []string{"a"}, wantErr: "--dest is required when SRC is given"},
+		{name: "test constraints on positional broken", args: []string{"--dest", "b", "--stdin", "a"}, wantErr: "SRC conflicts with --stdin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			p.String("", "", "dest", "", &Option{RequiredIf: []string{"SRC"}})
+			p.Bool(false, "", "stdin", "", nil)
+			p.PositionalString("", "SRC", "", &Option{ConflictsWith: []string{"stdin"}})
+			err := p.Parse(tt.args)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Parser.Parse() error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Parser.Parse() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package argparse
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// docColumns are the headers of the option tables of the generated reference
+var docColumns = []string{"Name", "Type", "Default", "Required", "Description", "Env"}
+
+// GenMarkdown writes the markdown reference page of p
+func (p *Parser) GenMarkdown(w io.Writer) error {
+	_, err := io.WriteString(w, p.markdownPage())
+	return err
+}
+
+// GenMarkdownTree writes the markdown pages of p and of every visible command
+// below it to dir, one file per command named after its path, e.g.
+// "tool-deploy.md"
+func (p *Parser) GenMarkdownTree(dir string) error {
+	return p.genTree(dir, ".md", (*Parser).GenMarkdown)
+}
+
+// GenReST writes the reStructuredText reference page of p
+func (p *Parser) GenReST(w io.Writer) error {
+	_, err := io.WriteString(w, p.restPage())
+	return err
+}
+
+// GenReSTTree writes the reStructuredText pages of p and of every visible
+// command below it to dir, named like those of GenMarkdownTree
+func (p *Parser) GenReSTTree(dir string) error {
+	return p.genTree(dir, ".rst", (*Parser).GenReST)
+}
+
+func (p *Parser) genTree(dir, ext string, gen func(*Parser, io.Writer) error) error {
+	f, err := os.Create(filepath.Join(dir, p.manName()+ext))
+	if err != nil {
+		return err
+	}
+	if err := gen(p, f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	for _, c := range p.visibleCommands() {
+		if err := c.genTree(dir, ext, gen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// docSections splits the args of p like help does: arguments, options, the
+// groups and the inherited options
+func (p *Parser) docSections() []section {
+	res := make([]section, 0)
+	for _, sec := range p.sections() {
+		if sec.title != "" {
+			res = append(res, sec)
+			continue
+		}
+
+		arguments := section{title: "Arguments"}
+		options := section{title: "Options"}
+		for _, v := range sec.args {
+			if v.positional {
+				arguments.args = append(arguments.args, v)
+			} else {
+				options.args = append(options.args, v)
+			}
+		}
+		for _, s := range []section{arguments, options} {
+			if len(s.args) > 0 {
+				res = append(res, s)
+			}
+		}
+	}
+
+	if inherited := p.inherited(); len(inherited) > 0 {
+		res = append(res, section{title: "Inherited Options", args: inherited})
+	}
+	return res
+}
+
+// docRow returns the cells of a in the order of docColumns
+func (p *Parser) docRow(a *arg) []string {
+	desc := a.description
+	required, env := "", ""
+	if a.opts != nil {
+		if len(a.opts.Choices) > 0 {
+			desc = strings.TrimSpace(fmt.Sprintf("%s (choices: %s)", desc, strings.Join(a.opts.Choices, ", ")))
+		}
+		if a.opts.Require {
+			required = "yes"
+		}
+		env = a.opts.Env
+	}
+	return []string{strings.TrimSpace(a.helpName()), a.getType(), a.defaultText(), required, desc, env}
+}
+
+// docTitle is the command path of p, e.g. "tool deploy"
+func (p *Parser) docTitle() string {
+	return strings.Join(strings.Fields(p.prog()+" "+p.path()), " ")
+}
+
+func (p *Parser) markdownPage() string {
+	sb := &strings.Builder{}
+
+	sb.WriteString("# " + p.docTitle() + "\n")
+	if p.description != "" {
+		sb.WriteString("\n" + p.description + "\n")
+	}
+	sb.WriteString("\n## Usage\n\n```\n" + p.synopsis() + "\n```\n")
+
+	for _, sec := range p.docSections() {
+		sb.WriteString("\n## " + sec.title + "\n\n")
+		if sec.description != "" {
+			sb.WriteString(sec.description + "\n\n")
+		}
+		sb.WriteString("| " + strings.Join(docColumns, " | ") + " |\n")
+		sb.WriteString(strings.Repeat("| --- ", len(docColumns)) + "|\n")
+		for _, v := range sec.args {
+			cells := p.docRow(v)
+			cells[0] = "`" + cells[0] + "`"
+			for i := range cells {
+				cells[i] = markdownCell(cells[i])
+			}
+			sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		}
+	}
+
+	if commands := p.visibleCommands(); len(commands) > 0 {
+		sb.WriteString("\n## Commands\n\n| Name | Aliases | Description |\n| --- | --- | --- |\n")
+		for _, c := range commands {
+			sb.WriteString(fmt.Sprintf("| [%s](%s.md) | %s | %s |\n", markdownCell(c.name), c.manName(), markdownCell(strings.Join(c.aliases, ", ")), markdownCell(c.description)))
+		}
+	}
+
+	if len(p.examples) > 0 {
+		sb.WriteString("\n## Examples\n")
+		for _, e := range p.examples {
+			if e.description != "" {
+				sb.WriteString("\n" + e.description + "\n")
+			}
+			sb.WriteString("\n```\n" + e.command + "\n```\n")
+		}
+	}
+
+	if p.epilog != "" {
+		sb.WriteString("\n" + p.epilog + "\n")
+	}
+
+	if p.parent != nil {
+		sb.WriteString(fmt.Sprintf("\n## See Also\n\n- [%s](%s.md)\n", p.parent.docTitle(), p.parent.manName()))
+	}
+
+	return sb.String()
+}
+
+// markdownCell escapes the pipes of s, which would end a table cell
+func markdownCell(s string) string {
+	return strings.Replace(s, "|", "\\|", -1)
+}
+
+func (p *Parser) restPage() string {
+	sb := &strings.Builder{}
+
+	writeRestTitle(sb, p.docTitle(), "=")
+	if p.description != "" {
+		sb.WriteString("\n" + p.description + "\n")
+	}
+	sb.WriteString("\n")
+	writeRestTitle(sb, "Usage", "-")
+	sb.WriteString("\n::\n\n   " + p.synopsis() + "\n")
+
+	for _, sec := range p.docSections() {
+		sb.WriteString("\n")
+		writeRestTitle(sb, sec.title, "-")
+		if sec.description != "" {
+			sb.WriteString("\n" + sec.description + "\n")
+		}
+		rows := [][]string{docColumns}
+		for _, v := range sec.args {
+			cells := p.docRow(v)
+			cells[0] = "``" + cells[0] + "``"
+			rows = append(rows, cells)
+		}
+		writeRestTable(sb, rows)
+	}
+
+	if commands := p.visibleCommands(); len(commands) > 0 {
+		sb.WriteString("\n")
+		writeRestTitle(sb, "Commands", "-")
+		rows := [][]string{{"Name", "Aliases", "Description"}}
+		for _, c := range commands {
+			rows = append(rows, []string{fmt.Sprintf(":doc:`%s <%s>`", c.name, c.manName()), strings.Join(c.aliases, ", "), c.description})
+		}
+		writeRestTable(sb, rows)
+	}
+
+	if len(p.examples) > 0 {
+		sb.WriteString("\n")
+		writeRestTitle(sb, "Examples", "-")
+		for _, e := range p.examples {
+			if e.description != "" {
+				sb.WriteString("\n" + e.description + "\n")
+			}
+			sb.WriteString("\n::\n\n   " + e.command + "\n")
+		}
+	}
+
+	if p.epilog != "" {
+		sb.WriteString("\n" + p.epilog + "\n")
+	}
+
+	if p.parent != nil {
+		sb.WriteString("\n")
+		writeRestTitle(sb, "See Also", "-")
+		sb.WriteString(fmt.Sprintf("\n- :doc:`%s <%s>`\n", p.parent.docTitle(), p.parent.manName()))
+	}
+
+	return sb.String()
+}
+
+// writeRestTitle writes title underlined with c
+func writeRestTitle(sb *strings.Builder, title, c string) {
+	sb.WriteString(title + "\n" + strings.Repeat(c, len(title)) + "\n")
+}
+
+// writeRestTable writes rows as a list-table, the first row being the header
+func writeRestTable(sb *strings.Builder, rows [][]string) {
+	sb.WriteString("\n.. list-table::\n   :header-rows: 1\n\n")
+	for _, row := range rows {
+		for i, cell := range row {
+			prefix := "     -"
+			if i == 0 {
+				prefix = "   * -"
+			}
+			sb.WriteString(strings.TrimRight(prefix+" "+cell, " ") + "\n")
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package argparse
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParser_GenDocs(t *testing.T) {
+	tests := []struct {
+		name string
+		ext  string
+		gen  func(*Parser, io.Writer) error
+	}{
+		{name: "test markdown", ext: ".md", gen: (*Parser).GenMarkdown},
+		{name: "test rest", ext: ".rst", gen: (*Parser).GenReST},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := docParser()
+			for _, c := range []*Parser{p, p.findCommand("deploy"), p.findCommand("remove")} {
+				buf := &bytes.Buffer{}
+				if err := tt.gen(c, buf); err != nil {
+					t.Fatalf("generate error = %v", err)
+				}
+				golden(t, filepath.Join("docs", c.manName()+tt.ext), buf.Bytes())
+			}
+		})
+	}
+}
+
+func TestParser_GenDocsTree(t *testing.T) {
+	tests := []struct {
+		name string
+		gen  func(*Parser, string) error
+		want []string
+	}{
+		{name: "test markdown", gen: (*Parser).GenMarkdownTree, want: []string{"tool-deploy.md", "tool-remove.md", "tool.md"}},
+		{name: "test rest", gen: (*Parser).GenReSTTree, want: []string{"tool-deploy.rst", "tool-remove.rst", "tool.rst"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "argparse")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			if err := tt.gen(docParser(), dir); err != nil {
+				t.Fatalf("generate tree error = %v", err)
+			}
+			files, err := ioutil.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(files))
+			for _, f := range files {
+				got = append(got, f.Name())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("files = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_GenMarkdownEscape(t *testing.T) {
+	p := New()
+	p.SetProg("tool")
+	p.AddCommand("pipe", "read a | b").AddAlias("p|q")
+	p.String("", "s", "sep", "separator like |", nil)
+
+	buf := &bytes.Buffer{}
+	if err := p.GenMarkdown(buf); err != nil {
+		t.Fatalf("Parser.GenMarkdown() error = %v", err)
+	}
+	for _, want := range []string{"| [pipe](tool-pipe.md) | p\\|q | read a \\| b |", "separator like \\|"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Parser.GenMarkdown() = %s, want %s", buf.String(), want)
+		}
+	}
+}
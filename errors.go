@@ -0,0 +1,98 @@
+package argparse
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrHelp is returned by Parse after printing help when it was asked for
+	ErrHelp = errors.New("argparse: help requested")
+	// ErrVersion is returned by Parse after printing the version when it was
+	// asked for
+	ErrVersion = errors.New("argparse: version requested")
+)
+
+// UnknownOptionError is returned by Parse when argv holds an option that was
+// never registered
+type UnknownOptionError struct {
+	// option as written, without any attached value
+	Option string
+	// closest registered names, empty when suggestions are disabled
+	Suggestions []string
+}
+
+func (e *UnknownOptionError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return fmt.Sprintf("unknown option %s", e.Option)
+	}
+	return fmt.Sprintf("unknown option %s, did you mean %s?", e.Option, strings.Join(e.Suggestions, " or "))
+}
+
+// AmbiguousOptionError is returned by Parse when an abbreviated long option
+// is a prefix of several registered names
+type AmbiguousOptionError struct {
+	// option as written, without any attached value
+	Option string
+	// every long option the prefix matches
+	Candidates []string
+}
+
+func (e *AmbiguousOptionError) Error() string {
+	return fmt.Sprintf("ambiguous option %s could match %s", e.Option, strings.Join(e.Candidates, ", "))
+}
+
+// UnknownCommandError is returned by Parse when the command given is not a
+// registered subcommand
+type UnknownCommandError struct {
+	Command string
+	// closest command names, empty when suggestions are disabled
+	Suggestions []string
+}
+
+func (e *UnknownCommandError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return fmt.Sprintf("unknown command %s", e.Command)
+	}
+	return fmt.Sprintf("unknown command %s, did you mean %s?", e.Command, strings.Join(e.Suggestions, " or "))
+}
+
+// ExclusiveError is returned by Parse when more than one option of a
+// mutually exclusive group was given, or none of a required one
+type ExclusiveError struct {
+	// options given on the command line, empty when a required one is missing
+	Given []string
+	// all options of the group
+	Group []string
+}
+
+func (e *ExclusiveError) Error() string {
+	if len(e.Given) == 0 {
+		return fmt.Sprintf("one of %s is required", strings.Join(e.Group, " | "))
+	}
+	return fmt.Sprintf("options %s can not be used together", strings.Join(e.Given, ", "))
+}
+
+// ConstraintError is returned by Parse when a Requires, ConflictsWith,
+// RequiredIf or RequiredUnless rule of an option is broken
+type ConstraintError struct {
+	// option carrying the rule
+	Option string
+	// option the rule refers to, the alternatives joined with " or " for
+	// RequiredUnless
+	Other string
+	// one of the Rule constants
+	Rule string
+}
+
+func (e *ConstraintError) Error() string {
+	switch e.Rule {
+	case RuleRequiredIf:
+		return fmt.Sprintf("%s is required when %s is given", e.Option, e.Other)
+	case RuleRequiredUnless:
+		return fmt.Sprintf("%s is required unless %s is given", e.Option, e.Other)
+	default:
+		return fmt.Sprintf("%s %s %s", e.Option, e.Rule, e.Other)
+	}
+}
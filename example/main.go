@@ -23,7 +23,6 @@ func main() {
 	p := argparse.New()
 
 	var configPath string
-	var versionFlag bool
 	var port int
 	var floatVal float64
 	var name *string
@@ -31,7 +30,7 @@ func main() {
 	var missReq int
 	_ = missReq
 
-	p.BoolVar(&versionFlag, false, "v", "version", "show version", nil)
+	p.Version("v", "version", "1.0.0")
 	p.StringVar(&configPath, "", "f", "config", "", nil)
 	p.IntVar(&port, 0, "p", "port", "http listen port", nil)
 	p.FloatVar(&floatVal, 1.2, "ff", "float", "float value", nil)
@@ -43,14 +42,16 @@ func main() {
 	name = p.String("", "n", "name", "show name", nil)
 
 	err := p.Parse(opts)
+	if err == argparse.ErrHelp || err == argparse.ErrVersion {
+		return
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	fmt.Printf(
-		"Config: %s\nVersionFlag: %v\nPort: %d\nFloatVal: %f\nName: %s\nItems: %v\n",
+		"Config: %s\nPort: %d\nFloatVal: %f\nName: %s\nItems: %v\n",
 		configPath,
-		versionFlag,
 		port,
 		floatVal,
 		*name,
@@ -0,0 +1,83 @@
+package argparse
+
+import (
+	"fmt"
+	"strings"
+)
+
+type exclusiveGroup struct {
+	args     []*arg
+	required bool
+}
+
+// MutuallyExclusive makes the options named by their long or short names
+// conflict with each other, at most one of them may be given. With required
+// set exactly one must be given.
+func (p *Parser) MutuallyExclusive(required bool, names ...string) {
+	if len(names) < 2 {
+		panic(fmt.Errorf("unable to add exclusive group: need two options, got %d", len(names)))
+	}
+
+	g := &exclusiveGroup{required: required}
+	for _, name := range names {
+		a := p.findArg(name)
+		if a == nil {
+			panic(fmt.Errorf("unable to add exclusive group: unknown option %s", name))
+		}
+		g.args = append(g.args, a)
+	}
+
+	p.exclusive = append(p.exclusive, g)
+}
+
+// findArg resolves a name as used in Go code, without dashes, to one of the
+// options of p, long names first
+func (p *Parser) findArg(name string) *arg {
+	if a := p.lnames[name]; a != nil {
+		return a
+	}
+	return p.snames[name]
+}
+
+// usage renders the group for the usage line, e.g. "[--json | --yaml]"
+func (g *exclusiveGroup) usage() string {
+	names := make([]string, 0, len(g.args))
+	for _, v := range g.args {
+		names = append(names, v.flag())
+	}
+	if g.required {
+		return fmt.Sprintf("(%s)", strings.Join(names, " | "))
+	}
+	return fmt.Sprintf("[%s]", strings.Join(names, " | "))
+}
+
+// check counts the options given on the command line, values from the
+// environment only make up for none being given to a required group
+func (g *exclusiveGroup) check() error {
+	given := make([]string, 0)
+	env := false
+	for _, v := range g.args {
+		if v.parsed && !v.fromEnv {
+			given = append(given, v.flag())
+		}
+		env = env || v.fromEnv
+	}
+
+	if len(given) > 1 || (g.required && len(given) == 0 && !env) {
+		group := make([]string, 0, len(g.args))
+		for _, v := range g.args {
+			group = append(group, v.flag())
+		}
+		return &ExclusiveError{Given: given, Group: group}
+	}
+	return nil
+}
+
+func (p *Parser) checkExclusive() error {
+	for _, g := range p.exclusive {
+		if err := g.check(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package argparse
+
+import (
+	"testing"
+)
+
+func TestParser_MutuallyExclusive(t *testing.T) {
+	tests := []struct {
+		name      string
+		names     []string
+		wantPanic bool
+	}{
+		{name: "test add exclusive group", names: []string{"json", "y", "table"}},
+		{name: "test add exclusive group with unknown option", names: []string{"json", "xml"}, wantPanic: true},
+		{name: "test add exclusive group with one option", names: []string{"json"}, wantPanic: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); (r != nil) != tt.wantPanic {
+					t.Errorf("Parser.MutuallyExclusive() panic = %v, wantPanic %v", r, tt.wantPanic)
+				}
+			}()
+			p := New()
+			p.Bool(false, "j", "json", "", nil)
+			p.Bool(false, "y", "yaml", "", nil)
+			p.Bool(false, "", "table", "", nil)
+			p.MutuallyExclusive(false, tt.names...)
+		})
+	}
+}
+
+func TestParser_ParseExclusive(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		required bool
+		wantErr  string
+	}{
+		{name: "test exclusive none given", args: []string{}},
+		{name: "test exclusive one given", args: []string{"--json"}},
+		{name: "test exclusive two given", args: []string{"-jy"}, wantErr: "options --json, --yaml can not be used together"},
+		{name: "test exclusive required one given", args: []string{"--table"}, required: true},
+		{name: "test exclusive required none given", args: []string{}, required: true, wantErr: "one of --json | --yaml | --table is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			p.Bool(false, "j", "json", "", nil)
+			p.Bool(false, "y", "yaml", "", nil)
+			p.Bool(false, "", "table", "", nil)
+			p.MutuallyExclusive(tt.required, "json", "yaml", "table")
+			err := p.Parse(tt.args)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Parser.Parse() error = %v", err)
+				}
+				return
+			}
+			if _, ok := err.(*ExclusiveError); !ok || err.Error() != tt.wantErr {
+				t.Errorf("Parser.Parse() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_exclusiveGroup_usage(t *testing.T) {
+	p := New()
+	p.Bool(false, "j", "json", "", nil)
+	p.Bool(false, "y", "", "", nil)
+	p.Int(0, "p", "port", "", nil)
+	p.MutuallyExclusive(false, "json", "y")
+	p.MutuallyExclusive(true, "j", "port")
+
+	want := "[options] [--json | -y] (--json | --port)"
+	if got := p.usage(); got != want {
+		t.Errorf("Parser.usage() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,81 @@
+package argparse
+
+import (
+	"errors"
+	"sort"
+)
+
+// Group is a titled section of options in help, see Parser.Group
+type Group struct {
+	Title       string
+	Description string
+	// Order sorts the groups in help, lower first. Groups with the same order
+	// keep the order they were created in.
+	Order int
+
+	parser *Parser
+	args   []*arg
+}
+
+// Group creates a help section. Options are put in it with Option.Group.
+func (p *Parser) Group(title, description string) *Group {
+	if title == "" {
+		panic(errors.New("unable to add group: title is empty"))
+	}
+
+	g := &Group{
+		Title:       title,
+		Description: description,
+		parser:      p,
+	}
+	p.groups = append(p.groups, g)
+
+	return g
+}
+
+// addToGroup files a under the group named in its options, if any
+func (p *Parser) addToGroup(a *arg) {
+	if a.opts == nil || a.opts.Group == nil {
+		return
+	}
+	if a.opts.Group.parser != p {
+		panic(errors.New("unable to add option: group belongs to another parser"))
+	}
+	a.opts.Group.args = append(a.opts.Group.args, a)
+}
+
+// section is a run of options rendered together in help and docs
+type section struct {
+	title       string
+	description string
+	args        []*arg
+}
+
+// sections splits the args of p into the ungrouped ones, listed first without
+// a title, followed by every non empty group in order
+func (p *Parser) sections() []section {
+	res := make([]section, 0, len(p.groups)+1)
+
+	ungrouped := make([]*arg, 0, len(p.args))
+	for _, v := range p.args {
+		if v.opts == nil || v.opts.Group == nil {
+			ungrouped = append(ungrouped, v)
+		}
+	}
+	if len(ungrouped) > 0 {
+		res = append(res, section{args: ungrouped})
+	}
+
+	groups := make([]*Group, len(p.groups))
+	copy(groups, p.groups)
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Order < groups[j].Order
+	})
+	for _, g := range groups {
+		if len(g.args) > 0 {
+			res = append(res, section{title: g.Title, description: g.Description, args: g.args})
+		}
+	}
+
+	return res
+}
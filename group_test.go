@@ -0,0 +1,61 @@
+package argparse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParser_sections(t *testing.T) {
+	p := New()
+	logging := p.Group("Logging", "")
+	network := p.Group("Network", "connection settings")
+	advanced := p.Group("Advanced", "")
+	empty := p.Group("Empty", "")
+	advanced.Order = 1
+	empty.Order = -1
+
+	p.Help("h", "help")
+	p.Int(0, "p", "port", "", &Option{Group: network})
+	p.Count(0, "v", "verbose", "", &Option{Group: logging})
+	p.String("", "", "host", "", &Option{Group: network})
+	p.Bool(false, "", "debug", "", &Option{Group: advanced})
+	p.PositionalString("", "SRC", "", nil)
+
+	got := make([]string, 0)
+	for _, sec := range p.sections() {
+		names := make([]string, 0)
+		for _, v := range sec.args {
+			names = append(names, v.name())
+		}
+		got = append(got, sec.title+": "+strings.Join(names, ", "))
+	}
+	want := []string{
+		": -h | --help, SRC",
+		"Logging: -v | --verbose",
+		"Network: -p | --port, --host",
+		"Advanced: --debug",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parser.sections() = %v, want %v", got, want)
+	}
+
+	help := p.helpText()
+	if !strings.Contains(help, "Network:\n  connection settings\n") {
+		t.Errorf("Parser.helpText() = %v, missing Network group", help)
+	}
+	if strings.Contains(help, "Empty:") {
+		t.Errorf("Parser.helpText() = %v, shows empty group", help)
+	}
+}
+
+func TestParser_GroupOtherParser(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Parser.Int() did not panic for a group of another parser")
+		}
+	}()
+	p := New()
+	g := New().Group("Network", "")
+	p.Int(0, "p", "port", "", &Option{Group: g})
+}
@@ -0,0 +1,306 @@
+package argparse
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// defaultWidth is used when neither SetWidth nor COLUMNS give one
+	defaultWidth = 80
+	// helpIndent is the indentation of help rows
+	helpIndent = 2
+	// helpGap separates the name column from the description
+	helpGap = 2
+	// maxNameWidth caps the name column, longer names get the description on
+	// the next line
+	maxNameWidth = 30
+	// minDescWidth keeps descriptions readable on narrow terminals
+	minDescWidth = 20
+)
+
+// SetWidth sets the width help is wrapped to. By default it is taken from
+// the COLUMNS environment variable, or 80. Set on the root parser it covers
+// all commands.
+func (p *Parser) SetWidth(width int) {
+	p.width = width
+}
+
+// helpWidth returns the width help text is wrapped to
+func (p *Parser) helpWidth() int {
+	if w := p.root().width; w > 0 {
+		return w
+	}
+	if w, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && w > 0 {
+		return w
+	}
+	return defaultWidth
+}
+
+// metavar returns the name of the value of a in help, "" for flags
+func (a *arg) metavar() string {
+	if a.opts != nil && a.opts.Metavar != "" {
+		return a.opts.Metavar
+	}
+	if a.size == 0 || a.positional {
+		return ""
+	}
+	if a.lname != "" {
+		return strings.ToUpper(strings.Replace(a.lname, "-", "_", -1))
+	}
+	return strings.ToUpper(a.sname)
+}
+
+// helpName renders the name column of a, e.g. "-p, --port PORT"
+func (a *arg) helpName() string {
+	if a.positional {
+		return a.name()
+	}
+
+	names := make([]string, 0, 2)
+	if a.sname != "" {
+		names = append(names, "-"+a.sname)
+	}
+	if a.lname != "" {
+		lname := a.lname
+		if a.negatable() {
+			lname = "[no-]" + lname
+		}
+		names = append(names, "--"+lname)
+	}
+
+	s := strings.Join(names, ", ")
+	if m := a.metavar(); m != "" {
+		s += " " + m
+	}
+	if a.sname == "" {
+		// keep long names in line with those following a short name
+		s = "    " + s
+	}
+	return s
+}
+
+// helpDescription is the description of a with its notes appended
+func (p *Parser) helpDescription(a *arg) string {
+	desc := a.description
+	if notes := p.notes(a); len(notes) > 0 {
+		desc = strings.TrimSpace(fmt.Sprintf("%s (%s)", desc, strings.Join(notes, ", ")))
+	}
+	return desc
+}
+
+type helpRow struct {
+	name        string
+	description string
+}
+
+type helpBlock struct {
+	title       string
+	description string
+	rows        []helpRow
+}
+
+// helpBlocks lays out every section of the help of p
+func (p *Parser) helpBlocks() []helpBlock {
+	blocks := make([]helpBlock, 0)
+
+	for _, sec := range p.sections() {
+		if sec.title != "" {
+			b := helpBlock{title: sec.title, description: sec.description}
+			for _, v := range sec.args {
+				b.rows = append(b.rows, helpRow{v.helpName(), p.helpDescription(v)})
+			}
+			blocks = append(blocks, b)
+			continue
+		}
+
+		// ungrouped args are split into arguments and options
+		arguments := helpBlock{title: "Arguments"}
+		options := helpBlock{title: "Options"}
+		for _, v := range sec.args {
+			row := helpRow{v.helpName(), p.helpDescription(v)}
+			if v.positional {
+				arguments.rows = append(arguments.rows, row)
+			} else {
+				options.rows = append(options.rows, row)
+			}
+		}
+		for _, b := range []helpBlock{arguments, options} {
+			if len(b.rows) > 0 {
+				blocks = append(blocks, b)
+			}
+		}
+	}
+
+	if inherited := p.inherited(); len(inherited) > 0 {
+		b := helpBlock{title: "Inherited Options"}
+		for _, v := range inherited {
+			b.rows = append(b.rows, helpRow{v.helpName(), p.helpDescription(v)})
+		}
+		blocks = append(blocks, b)
+	}
+
+	if commands := p.visibleCommands(); len(commands) > 0 {
+		b := helpBlock{title: "Commands"}
+		for _, c := range commands {
+			name := c.name
+			if len(c.aliases) > 0 {
+				name = fmt.Sprintf("%s (%s)", name, strings.Join(c.aliases, ", "))
+			}
+			b.rows = append(b.rows, helpRow{name, c.description})
+		}
+		blocks = append(blocks, b)
+	}
+
+	if plugins := p.discoverPlugins(); len(plugins) > 0 {
+		b := helpBlock{title: "Plugins"}
+		for _, name := range plugins {
+			b.rows = append(b.rows, helpRow{name: name})
+		}
+		blocks = append(blocks, b)
+	}
+
+	return blocks
+}
+
+func (p *Parser) helpText() string {
+	width := p.helpWidth()
+	sb := &strings.Builder{}
+
+	// continuation lines are indented to line up after the prefix
+	prefix := "Usage: "
+	indent := "\n" + strings.Repeat(" ", len(prefix))
+	if p.usageText != "" {
+		// a custom usage is laid out by the program already
+		sb.WriteString(prefix + p.usageText)
+	} else {
+		sb.WriteString(prefix + strings.Join(wrap(p.synopsis(), width-len(prefix)), indent))
+	}
+	sb.WriteString("\n")
+	if p.description != "" {
+		sb.WriteString("\n")
+		sb.WriteString(strings.Join(wrapText(p.description, width), "\n"))
+		sb.WriteString("\n")
+	}
+
+	blocks := p.helpBlocks()
+	nameWidth := 0
+	for _, b := range blocks {
+		for _, r := range b.rows {
+			if n := utf8.RuneCountInString(r.name); n > nameWidth && n <= maxNameWidth {
+				nameWidth = n
+			}
+		}
+	}
+
+	for _, b := range blocks {
+		sb.WriteString(fmt.Sprintf("\n%s:\n", b.title))
+		if b.description != "" {
+			for _, line := range wrap(b.description, width-helpIndent) {
+				sb.WriteString(fmt.Sprintf("%s%s\n", strings.Repeat(" ", helpIndent), line))
+			}
+			sb.WriteString("\n")
+		}
+		for _, r := range b.rows {
+			writeHelpRow(sb, r, nameWidth, width)
+		}
+	}
+
+	if len(p.examples) > 0 {
+		sb.WriteString("\nExamples:\n")
+		for _, e := range p.examples {
+			for _, line := range wrap(e.description, width-helpIndent) {
+				sb.WriteString(strings.Repeat(" ", helpIndent) + line + "\n")
+			}
+			sb.WriteString(strings.Repeat(" ", helpIndent*2) + e.command + "\n")
+		}
+	}
+
+	if p.epilog != "" {
+		sb.WriteString("\n")
+		sb.WriteString(strings.Join(wrapText(p.epilog, width), "\n"))
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// writeHelpRow writes a name and its description wrapped into the
+// description column
+func writeHelpRow(sb *strings.Builder, r helpRow, nameWidth, width int) {
+	indent := strings.Repeat(" ", helpIndent)
+	descCol := helpIndent + nameWidth + helpGap
+	descWidth := width - descCol
+	if descWidth < minDescWidth {
+		descWidth = minDescWidth
+	}
+
+	lines := wrap(r.description, descWidth)
+	sb.WriteString(indent + r.name)
+	if len(lines) == 0 {
+		sb.WriteString("\n")
+		return
+	}
+
+	nameLen := utf8.RuneCountInString(r.name)
+	if nameLen > nameWidth {
+		sb.WriteString("\n" + strings.Repeat(" ", descCol))
+	} else {
+		sb.WriteString(strings.Repeat(" ", descCol-helpIndent-nameLen))
+	}
+	sb.WriteString(strings.Join(lines, "\n"+strings.Repeat(" ", descCol)))
+	sb.WriteString("\n")
+}
+
+// wrapText wraps every paragraph of s on its own. Paragraphs are separated
+// by blank lines, which are kept, and indented lines like those of a list
+// are kept as they are.
+func wrapText(s string, width int) []string {
+	lines := make([]string, 0)
+	para := make([]string, 0)
+	flush := func() {
+		lines = append(lines, wrap(strings.Join(para, " "), width)...)
+		para = para[:0]
+	}
+	for _, line := range strings.Split(strings.TrimRight(s, "\n"), "\n") {
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+			lines = append(lines, "")
+		case line[0] == ' ' || line[0] == '\t':
+			flush()
+			lines = append(lines, line)
+		default:
+			para = append(para, line)
+		}
+	}
+	flush()
+	return lines
+}
+
+// wrap splits s into lines of at most width characters, breaking at spaces.
+// Words longer than width get a line of their own.
+func wrap(s string, width int) []string {
+	words := strings.Fields(s)
+	lines := make([]string, 0)
+	line, n := "", 0
+	for _, w := range words {
+		wn := utf8.RuneCountInString(w)
+		switch {
+		case line == "":
+			line, n = w, wn
+		case n+1+wn <= width:
+			line, n = line+" "+w, n+1+wn
+		default:
+			lines = append(lines, line)
+			line, n = w, wn
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,278 @@
+package argparse
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_wrap(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		width int
+		want  []string
+	}{
+		{name: "test wrap short text", s: "listen port", width: 20, want: []string{"listen port"}},
+		{name: "test wrap at spaces", s: "the port the http server listens on", width: 15, want: []string{"the port the", "http server", "listens on"}},
+		{name: "test wrap long word", s: "a verylongwordindeed b", width: 5, want: []string{"a", "verylongwordindeed", "b"}},
+		{name: "test wrap empty text", s: "", width: 5, want: []string{}},
+		{name: "test wrap counts runes", s: "größe über alles", width: 10, want: []string{"größe über", "alles"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wrap(tt.s, tt.width); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("wrap() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_wrapText(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		width int
+		want  []string
+	}{
+		{name: "test wrap text single paragraph", s: "the port the\nhttp server listens on", width: 15, want: []string{"the port the", "http server", "listens on"}},
+		{name: "test wrap text paragraphs", s: "First paragraph.\n\nSecond one.", width: 40, want: []string{"First paragraph.", "", "Second one."}},
+		{name: "test wrap text indented lines", s: "Second:\n  - a\n  - b", width: 40, want: []string{"Second:", "  - a", "  - b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wrapText(tt.s, tt.width); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("wrapText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_writeHelpRow(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []helpRow
+		want string
+	}{
+		{
+			name: "test help rows aligned by runes",
+			rows: []helpRow{{"--größe GRÖSSE", "maße"}, {"--size SIZE", "size"}},
+			want: "  --größe GRÖSSE  maße\n  --size SIZE     size\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sb := &strings.Builder{}
+			for _, r := range tt.rows {
+				writeHelpRow(sb, r, 14, 80)
+			}
+			if got := sb.String(); got != tt.want {
+				t.Errorf("writeHelpRow() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_arg_helpName(t *testing.T) {
+	tests := []struct {
+		name string
+		a    *arg
+		want string
+	}{
+		{name: "test help name with metavar", a: &arg{sname: "p", lname: "port", size: 1}, want: "-p, --port PORT"},
+		{name: "test help name of flag", a: &arg{sname: "v", size: 0}, want: "-v"},
+		{name: "test help name long only", a: &arg{lname: "dry-run", size: 1}, want: "    --dry-run DRY_RUN"},
+		{name: "test help name custom metavar", a: &arg{sname: "f", size: 1, opts: &Option{Metavar: "FILE"}}, want: "-f FILE"},
+		{name: "test help name negatable", a: &arg{lname: "cache", value: new(bool), opts: &Option{Negatable: true}}, want: "    --[no-]cache"},
+		{name: "test help name positional", a: &arg{lname: "FILES", positional: true}, want: "FILES..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.helpName(); got != tt.want {
+				t.Errorf("arg.helpName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_helpWidth(t *testing.T) {
+	defer os.Setenv("COLUMNS", os.Getenv("COLUMNS"))
+
+	p := New()
+	os.Setenv("COLUMNS", "")
+	if got := p.helpWidth(); got != defaultWidth {
+		t.Errorf("Parser.helpWidth() = %v, want %v", got, defaultWidth)
+	}
+	os.Setenv("COLUMNS", "120")
+	if got := p.helpWidth(); got != 120 {
+		t.Errorf("Parser.helpWidth() = %v, want 120", got)
+	}
+	p.AddCommand("deploy", "")
+	p.SetWidth(60)
+	if got := p.commands[0].helpWidth(); got != 60 {
+		t.Errorf("Parser.helpWidth() = %v, want 60", got)
+	}
+}
+
+func TestParser_helpText(t *testing.T) {
+	p := New()
+	p.SetProg("tool")
+	p.SetWidth(60)
+	p.Help("h", "help")
+	p.Int(3000, "p", "port", "the port the http server listens on for incoming requests", nil)
+	p.Count(0, "v", "verbose", "more output", nil)
+	p.String("", "", "a-very-long-option-name-indeed", "long", nil)
+	p.AddCommand("deploy", "deploy the service").AddAlias("d")
+
+	want := `Usage: tool [options] <command>
+
+Options:
+  -h, --help       show usage help
+  -p, --port PORT  the port the http server listens on for
+                   incoming requests (default: 3000)
+  -v, --verbose    more output (repeatable)
+      --a-very-long-option-name-indeed A_VERY_LONG_OPTION_NAME_INDEED
+                   long
+
+Commands:
+  deploy (d)       deploy the service
+`
+	if got := p.helpText(); got != want {
+		t.Errorf("Parser.helpText() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestParser_helpTextArguments(t *testing.T) {
+	p := New()
+	p.SetProg("cp")
+	p.Bool(false, "f", "force", "", nil)
+	p.PositionalString("", "SRC", "source directory", &Option{Require: true})
+	p.PositionalStringSlice(nil, "FILES", "files to copy", nil)
+
+	want := `Usage: cp [options] SRC [FILES...]
+
+Arguments:
+  SRC          source directory (required)
+  FILES...     files to copy
+
+Options:
+  -f, --force
+`
+	if got := p.helpText(); got != want {
+		t.Errorf("Parser.helpText() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestParser_notes(t *testing.T) {
+	tests := []struct {
+		name string
+		a    *arg
+		want []string
+	}{
+		{name: "test notes of plain option", a: &arg{lname: "name", defaultValue: ""}, want: []string{}},
+		{name: "test notes with default", a: &arg{lname: "port", defaultValue: 3000}, want: []string{"default: 3000"}},
+		{name: "test notes with slice default", a: &arg{lname: "item", defaultValue: []string{"a", "b"}}, want: []string{"default: a, b"}},
+		{name: "test notes with hidden default", a: &arg{lname: "port", defaultValue: 3000, opts: &Option{HideDefault: true}}, want: []string{}},
+		{name: "test notes with default text", a: &arg{lname: "home", defaultValue: "/root", opts: &Option{DefaultText: "$HOME"}}, want: []string{"default: $HOME"}},
+		{
+			name: "test notes with required and choices",
+			a:    &arg{lname: "format", defaultValue: "json", opts: &Option{Require: true, Choices: []string{"json", "yaml"}}},
+			want: []string{"required", "choices: json, yaml", "default: json"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New().notes(tt.a); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parser.notes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_helpTextLayout(t *testing.T) {
+	p := New()
+	p.SetProg("tool")
+	p.SetUsage("tool deploy [options] TARGET\n  or:  tool remove RELEASE")
+	p.SetDescription("Tool manages deployments.")
+	p.SetEpilog("First paragraph.\n\nSecond:\n  - a\n  - b")
+
+	want := `Usage: tool deploy [options] TARGET
+  or:  tool remove RELEASE
+
+Tool manages deployments.
+
+First paragraph.
+
+Second:
+  - a
+  - b
+`
+	if got := p.helpText(); got != want {
+		t.Errorf("Parser.helpText() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestParser_helpTextUsageWidth(t *testing.T) {
+	p := New()
+	p.SetProg("tool")
+	p.SetWidth(30)
+	p.PositionalString("", "SOURCE_DIRECTORY", "", nil)
+	p.PositionalString("", "TARGET_DIRECTORY", "", nil)
+	p.PositionalStringSlice(nil, "FILES", "", nil)
+
+	want := `Usage: tool [SOURCE_DIRECTORY]
+       [TARGET_DIRECTORY]
+       [FILES...]
+`
+	if got := p.helpText(); !strings.HasPrefix(got, want) {
+		t.Errorf("Parser.helpText() =\n%s\nwant prefix\n%s", got, want)
+	}
+}
+
+func TestParser_helpTextProgram(t *testing.T) {
+	p := New()
+	p.SetProg("tool")
+	p.SetWidth(40)
+	p.SetDescription("tool deploys services.")
+	p.SetEpilog("Report bugs to the issue tracker of the project.")
+	p.AddExample("tool deploy --env prod", "Deploy the current release to production")
+	p.Bool(false, "v", "", "", nil)
+
+	want := `Usage: tool [options]
+
+tool deploys services.
+
+Options:
+  -v
+
+Examples:
+  Deploy the current release to
+  production
+    tool deploy --env prod
+
+Report bugs to the issue tracker of the
+project.
+`
+	if got := p.helpText(); got != want {
+		t.Errorf("Parser.helpText() =\n%s\nwant\n%s", got, want)
+	}
+
+	p.SetUsage("tool [-v]")
+	if got := p.synopsis(); got != "tool [-v]" {
+		t.Errorf("Parser.synopsis() = %v, want %v", got, "tool [-v]")
+	}
+}
+
+func TestParser_prog(t *testing.T) {
+	p := New()
+	c := p.AddCommand("deploy", "")
+	if got, want := c.prog(), filepath.Base(os.Args[0]); got != want {
+		t.Errorf("Parser.prog() = %v, want %v", got, want)
+	}
+	p.SetProg("tool")
+	if got := c.synopsis(); got != "tool deploy" {
+		t.Errorf("Parser.synopsis() = %v, want %v", got, "tool deploy")
+	}
+}
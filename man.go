@@ -0,0 +1,219 @@
+package argparse
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GenMan writes the man(7) page of p for the given manual section, e.g. "1".
+// The page has the NAME, SYNOPSIS, DESCRIPTION, OPTIONS, COMMANDS and EXIT
+// STATUS sections, those without content are left out. The exit statuses
+// are listed under ExitOnError, otherwise the page says the program sets
+// them.
+func (p *Parser) GenMan(w io.Writer, section string) error {
+	_, err := io.WriteString(w, p.manPage(section))
+	return err
+}
+
+// GenManTree writes the man page of p and of every visible command below it
+// to dir, one file per command named after its path, e.g. "tool-deploy.1"
+func (p *Parser) GenManTree(dir, section string) error {
+	f, err := os.Create(filepath.Join(dir, p.manName()+"."+section))
+	if err != nil {
+		return err
+	}
+	if err := p.GenMan(f, section); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	for _, c := range p.visibleCommands() {
+		if err := c.GenManTree(dir, section); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// manName is the page name of p, its command path joined with dashes
+func (p *Parser) manName() string {
+	return strings.Join(strings.Fields(p.prog()+" "+p.path()), "-")
+}
+
+func (p *Parser) manPage(section string) string {
+	sb := &strings.Builder{}
+
+	sb.WriteString(fmt.Sprintf(".TH \"%s\" \"%s\" \"\" \"\" \"\"\n", manEscape(strings.ToUpper(p.manName())), section))
+
+	sb.WriteString(".SH NAME\n")
+	sb.WriteString(manEscape(p.manName()))
+	if p.description != "" {
+		sb.WriteString(" \\- " + manEscape(p.description))
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString(".SH SYNOPSIS\n")
+	if p.usageText != "" {
+		sb.WriteString(manEscape(p.usageText) + "\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("\\fB%s\\fR %s\n", manEscape(strings.Join(strings.Fields(p.prog()+" "+p.path()), " ")), manEscape(p.usage())))
+	}
+
+	if p.description != "" || p.epilog != "" {
+		sb.WriteString(".SH DESCRIPTION\n")
+		for _, s := range []string{p.description, p.epilog} {
+			if s != "" {
+				sb.WriteString(".PP\n" + manEscape(s) + "\n")
+			}
+		}
+	}
+
+	arguments := make([]*arg, 0)
+	for _, sec := range p.sections() {
+		if sec.title != "" {
+			continue
+		}
+		for _, v := range sec.args {
+			if v.positional {
+				arguments = append(arguments, v)
+			}
+		}
+	}
+	if len(arguments) > 0 {
+		sb.WriteString(".SH ARGUMENTS\n")
+		for _, v := range arguments {
+			p.writeManArg(sb, v)
+		}
+	}
+
+	options := false
+	for _, sec := range p.sections() {
+		if sec.title != "" {
+			if !options {
+				sb.WriteString(".SH OPTIONS\n")
+				options = true
+			}
+			sb.WriteString(".SS " + manEscape(sec.title) + "\n")
+			if sec.description != "" {
+				sb.WriteString(manEscape(sec.description) + "\n")
+			}
+		}
+		for _, v := range sec.args {
+			if v.positional && sec.title == "" {
+				continue
+			}
+			if !options {
+				sb.WriteString(".SH OPTIONS\n")
+				options = true
+			}
+			p.writeManArg(sb, v)
+		}
+	}
+	if inherited := p.inherited(); len(inherited) > 0 {
+		sb.WriteString(".SH INHERITED OPTIONS\n")
+		for _, v := range inherited {
+			p.writeManArg(sb, v)
+		}
+	}
+
+	commands := p.visibleCommands()
+	if len(commands) > 0 {
+		sb.WriteString(".SH COMMANDS\n")
+		for _, c := range commands {
+			name := "\\fB" + manEscape(c.name) + "\\fR"
+			if len(c.aliases) > 0 {
+				name += " (" + manEscape(strings.Join(c.aliases, ", ")) + ")"
+			}
+			sb.WriteString(".TP\n" + name + "\n")
+			if c.description != "" {
+				sb.WriteString(manEscape(c.description) + "\n")
+			}
+		}
+	}
+
+	if len(p.examples) > 0 {
+		sb.WriteString(".SH EXAMPLES\n")
+		for _, e := range p.examples {
+			if e.description != "" {
+				sb.WriteString(".PP\n" + manEscape(e.description) + "\n")
+			}
+			sb.WriteString(".PP\n.RS 4\n.nf\n" + manEscape(e.command) + "\n.fi\n.RE\n")
+		}
+	}
+
+	sb.WriteString(".SH EXIT STATUS\n")
+	if p.root().errorHandling == ExitOnError {
+		sb.WriteString(".TP\n0\nSuccess, also after printing help or the version.\n")
+		sb.WriteString(".TP\n2\nThe command line could not be parsed.\n")
+	} else {
+		sb.WriteString("The exit status is set by the program, including when the command line could not be parsed.\n")
+	}
+
+	related := make([]string, 0, len(commands)+1)
+	if p.parent != nil {
+		related = append(related, p.parent.manName())
+	}
+	for _, c := range commands {
+		related = append(related, c.manName())
+	}
+	if len(related) > 0 {
+		sb.WriteString(".SH SEE ALSO\n")
+		for i, name := range related {
+			related[i] = fmt.Sprintf("\\fB%s\\fR(%s)", manEscape(name), section)
+		}
+		sb.WriteString(strings.Join(related, ", ") + "\n")
+	}
+
+	return sb.String()
+}
+
+// writeManArg writes a as a tagged paragraph, its description followed by
+// its type and notes
+func (p *Parser) writeManArg(sb *strings.Builder, a *arg) {
+	sb.WriteString(".TP\n")
+	if a.positional {
+		sb.WriteString("\\fI" + manEscape(a.name()) + "\\fR\n")
+	} else {
+		names := make([]string, 0, 2)
+		if a.sname != "" {
+			names = append(names, "\\fB"+manEscape("-"+a.sname)+"\\fR")
+		}
+		if a.lname != "" {
+			lname := a.lname
+			if a.negatable() {
+				lname = "[no-]" + lname
+			}
+			names = append(names, "\\fB"+manEscape("--"+lname)+"\\fR")
+		}
+		s := strings.Join(names, ", ")
+		if m := a.metavar(); m != "" {
+			s += " \\fI" + manEscape(m) + "\\fR"
+		}
+		sb.WriteString(s + "\n")
+	}
+
+	if a.description != "" {
+		sb.WriteString(manEscape(a.description) + "\n.br\n")
+	}
+	notes := append([]string{"type: " + a.getType()}, p.notes(a)...)
+	sb.WriteString(manEscape(strings.Join(notes, ", ")) + "\n")
+}
+
+// manEscape escapes s for roff, keeping lines from being read as requests
+func manEscape(s string) string {
+	s = strings.Replace(s, "\\", "\\e", -1)
+	s = strings.Replace(s, "-", "\\-", -1)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = "\\&" + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,140 @@
+package argparse
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files under testdata")
+
+// golden compares got with the file testdata/name, rewriting it with -update
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := ioutil.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s =\n%s\nwant\n%s", name, got, want)
+	}
+}
+
+// docParser is the command tree the generated docs are tested against
+func docParser() *Parser {
+	p := New()
+	p.SetProg("tool")
+	p.SetErrorHandling(ExitOnError)
+	p.SetDescription("Tool manages deployments.")
+	p.SetEpilog("Report bugs to the issue tracker.")
+	p.AddExample("tool deploy -t prod", "Deploy to production:")
+	p.Help("h", "help")
+	p.Version("", "version", "1.0.0")
+	p.Count(0, "v", "verbose", "more output", &Option{Persistent: true})
+	p.String("config.yaml", "c", "config", "config file", nil)
+
+	deploy := p.AddCommand("deploy", "Deploy the service.")
+	deploy.AddAlias("d")
+	deploy.String("", "t", "target", "target environment", &Option{Require: true, Choices: []string{"prod", "staging"}})
+	network := deploy.Group("Network", "Connection settings.")
+	deploy.Int(3000, "p", "port", "listen port", &Option{Group: network, Env: "TOOL_PORT"})
+	deploy.Bool(true, "", "tls", "use tls", &Option{Group: network, Negatable: true})
+
+	remove := p.AddCommand("remove", "Remove a release.")
+	remove.PositionalString("", "release", "release name", &Option{Require: true})
+	remove.PositionalStringSlice(nil, "files", "files to remove", nil)
+
+	p.AddCommand("internal", "").SetHidden(true)
+	return p
+}
+
+func TestParser_GenMan(t *testing.T) {
+	p := docParser()
+	for _, c := range []*Parser{p, p.findCommand("deploy"), p.findCommand("remove")} {
+		buf := &bytes.Buffer{}
+		if err := c.GenMan(buf, "1"); err != nil {
+			t.Fatalf("Parser.GenMan() error = %v", err)
+		}
+		golden(t, filepath.Join("man", c.manName()+".1"), buf.Bytes())
+	}
+}
+
+func TestParser_GenManExitStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		handling ErrorHandling
+		want     string
+	}{
+		{name: "test exit status on exit", handling: ExitOnError, want: ".SH EXIT STATUS\n.TP\n0\n"},
+		{name: "test exit status on continue", handling: ContinueOnError, want: ".SH EXIT STATUS\nThe exit status is set by the program"},
+		{name: "test exit status on panic", handling: PanicOnError, want: ".SH EXIT STATUS\nThe exit status is set by the program"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			p.SetProg("tool")
+			p.SetErrorHandling(tt.handling)
+			buf := &bytes.Buffer{}
+			if err := p.AddCommand("deploy", "").GenMan(buf, "1"); err != nil {
+				t.Fatalf("Parser.GenMan() error = %v", err)
+			}
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("Parser.GenMan() = %s, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_GenManTree(t *testing.T) {
+	dir, err := ioutil.TempDir("", "argparse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := docParser().GenManTree(dir, "1"); err != nil {
+		t.Fatalf("Parser.GenManTree() error = %v", err)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]string, 0, len(files))
+	for _, f := range files {
+		got = append(got, f.Name())
+	}
+	want := []string{"tool-deploy.1", "tool-remove.1", "tool.1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parser.GenManTree() files = %v, want %v", got, want)
+	}
+}
+
+func Test_manEscape(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{name: "test dashes", s: "--port", want: "\\-\\-port"},
+		{name: "test backslash", s: `a\b`, want: `a\eb`},
+		{name: "test leading dot", s: ".hidden\n'quoted", want: "\\&.hidden\n\\&'quoted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := manEscape(tt.s); got != tt.want {
+				t.Errorf("manEscape() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
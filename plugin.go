@@ -0,0 +1,124 @@
+package argparse
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// SetPlugins lets unknown commands run an executable named
+// "<prog>-<command>" found on PATH, like git and kubectl do. Set on the root
+// parser it covers all commands.
+func (p *Parser) SetPlugins(enabled bool) {
+	p.plugins = enabled
+}
+
+// Plugin returns the path of the external command selected on the command
+// line, or "" when none was. Its arguments are in Args.
+func (p *Parser) Plugin() string {
+	return p.plugin
+}
+
+// prog returns the program name, the base name of os.Args[0] by default
+func (p *Parser) prog() string {
+	r := p.root()
+	if r.progName != "" {
+		return r.progName
+	}
+	if len(os.Args) == 0 {
+		return ""
+	}
+	return filepath.Base(os.Args[0])
+}
+
+// pluginPrefix is the executable name prefix of plugins below p, e.g.
+// "tool-" for the root parser or "tool-deploy-" for the deploy command
+func (p *Parser) pluginPrefix() string {
+	parts := []string{p.prog()}
+	if path := p.path(); path != "" {
+		parts = append(parts, strings.Fields(path)...)
+	}
+	return strings.Join(parts, "-") + "-"
+}
+
+// lookPlugin returns the path of the plugin executable for command name
+func (p *Parser) lookPlugin(name string) (string, bool) {
+	if !p.root().plugins || p.prog() == "" {
+		return "", false
+	}
+	path, err := exec.LookPath(p.pluginPrefix() + name)
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// discoverPlugins returns the command names of all plugins found on PATH,
+// sorted and without those shadowed by registered commands
+func (p *Parser) discoverPlugins() []string {
+	if !p.root().plugins || p.prog() == "" {
+		return nil
+	}
+
+	prefix := p.pluginPrefix()
+	seen := make(map[string]bool)
+	res := make([]string, 0)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			dir = "."
+		}
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			name := strings.TrimPrefix(f.Name(), prefix)
+			if name == f.Name() || name == "" || f.IsDir() || f.Mode()&0111 == 0 {
+				continue
+			}
+			name = trimExecExt(name)
+			if seen[name] || p.findCommand(name) != nil {
+				continue
+			}
+			seen[name] = true
+			res = append(res, name)
+		}
+	}
+
+	sort.Strings(res)
+	return res
+}
+
+// trimExecExt strips the extensions exec.LookPath adds by itself, those of
+// PATHEXT on Windows, so a listed plugin name resolves back to its file
+func trimExecExt(name string) string {
+	if runtime.GOOS != "windows" {
+		return name
+	}
+	exts := os.Getenv("PATHEXT")
+	if exts == "" {
+		exts = ".com;.exe;.bat;.cmd"
+	}
+	ext := filepath.Ext(name)
+	for _, e := range filepath.SplitList(exts) {
+		if ext != "" && strings.EqualFold(e, ext) {
+			return strings.TrimSuffix(name, ext)
+		}
+	}
+	return name
+}
+
+// runPlugin executes the selected plugin with the remaining arguments and
+// the environment of the current process
+func (p *Parser) runPlugin() error {
+	cmd := exec.Command(p.plugin, p.rest...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	return cmd.Run()
+}
@@ -0,0 +1,176 @@
+package argparse
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// pluginDir creates a directory of stub plugin scripts and puts it first on
+// PATH until the returned func is called
+func pluginDir(t *testing.T, names ...string) (string, func()) {
+	if runtime.GOOS == "windows" {
+		t.Skip("stub plugins are shell scripts")
+	}
+	dir, err := ioutil.TempDir("", "argparse-plugin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		script := "#!/bin/sh\necho \"$PLUGIN_GREETING $*\" > \"$PLUGIN_OUT\"\n"
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "tool-noexec"), []byte(""), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+	return dir, func() {
+		os.Setenv("PATH", path)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestParser_ParsePlugin(t *testing.T) {
+	dir, cleanup := pluginDir(t, "tool-hello", "tool-deploy-canary")
+	defer cleanup()
+
+	tests := []struct {
+		name       string
+		args       []string
+		disable    bool
+		wantPlugin string
+		wantArgs   []string
+		wantErr    bool
+	}{
+		{
+			name:       "test parse root plugin",
+			args:       []string{"-v", "hello", "--name", "world"},
+			wantPlugin: "tool-hello",
+			wantArgs:   []string{"--name", "world"},
+		},
+		{
+			name:       "test parse command plugin",
+			args:       []string{"deploy", "canary", "1"},
+			wantPlugin: "tool-deploy-canary",
+			wantArgs:   []string{"1"},
+		},
+		{
+			name:    "test parse plugin not executable",
+			args:    []string{"noexec"},
+			wantErr: true,
+		},
+		{
+			name:    "test parse plugins disabled",
+			args:    []string{"hello"},
+			disable: true,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			p.SetProg("tool")
+			p.SetPlugins(!tt.disable)
+			p.Bool(false, "v", "verbose", "", nil)
+			p.AddCommand("status", "")
+			p.AddCommand("deploy", "").AddCommand("rollout", "")
+
+			err := p.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parser.Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			c := p
+			for c.Command() != nil {
+				c = c.Command()
+			}
+			if got := c.Plugin(); got != filepath.Join(dir, tt.wantPlugin) {
+				t.Errorf("Parser.Plugin() = %v, want %v", got, tt.wantPlugin)
+			}
+			if got := c.Args(); !reflect.DeepEqual(got, tt.wantArgs) {
+				t.Errorf("Parser.Args() = %v, want %v", got, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestParser_RunPlugin(t *testing.T) {
+	dir, cleanup := pluginDir(t, "tool-hello")
+	defer cleanup()
+
+	out := filepath.Join(dir, "out")
+	os.Setenv("PLUGIN_OUT", out)
+	os.Setenv("PLUGIN_GREETING", "hi")
+	defer os.Unsetenv("PLUGIN_OUT")
+	defer os.Unsetenv("PLUGIN_GREETING")
+
+	p := New()
+	p.SetProg("tool")
+	p.SetPlugins(true)
+	if err := p.Run([]string{"hello", "--name", "world"}); err != nil {
+		t.Fatalf("Parser.Run() error = %v", err)
+	}
+
+	b, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.TrimSpace(string(b)), "hi --name world"; got != want {
+		t.Errorf("plugin output = %q, want %q", got, want)
+	}
+}
+
+func TestParser_discoverPlugins(t *testing.T) {
+	_, cleanup := pluginDir(t, "tool-hello", "tool-status", "tool-deploy-canary", "other-tool")
+	defer cleanup()
+
+	p := New()
+	p.SetProg("tool")
+	p.SetPlugins(true)
+	p.AddCommand("status", "")
+	deploy := p.AddCommand("deploy", "")
+
+	if got, want := p.discoverPlugins(), []string{"deploy-canary", "hello"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Parser.discoverPlugins() = %v, want %v", got, want)
+	}
+	if got, want := deploy.discoverPlugins(), []string{"canary"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Parser.discoverPlugins() = %v, want %v", got, want)
+	}
+}
+
+func TestParser_discoverPluginsRoundTrip(t *testing.T) {
+	_, cleanup := pluginDir(t, "tool-hello", "tool-lint.sh")
+	defer cleanup()
+
+	p := New()
+	p.SetProg("tool")
+	p.SetPlugins(true)
+	names := p.discoverPlugins()
+	if want := []string{"hello", "lint.sh"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("Parser.discoverPlugins() = %v, want %v", names, want)
+	}
+
+	for _, name := range names {
+		p := New()
+		p.SetProg("tool")
+		p.SetPlugins(true)
+		if err := p.Parse([]string{name}); err != nil {
+			t.Errorf("Parser.Parse(%q) error = %v", name, err)
+			continue
+		}
+		if got := filepath.Base(p.Plugin()); got != "tool-"+name {
+			t.Errorf("Parser.Plugin() = %v, want tool-%v", got, name)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package argparse
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+func (p *Parser) positionalVar(i interface{}, defVal interface{}, name, description string, unique bool, opts *Option) {
+	t := reflect.ValueOf(i)
+	if t.Kind() != reflect.Ptr {
+		panic(errors.New("var type not ptr"))
+	}
+	if name == "" {
+		panic(errors.New("positional name is empty"))
+	}
+	if len(p.commands) > 0 {
+		panic(errors.New("unable to add positional: parser has commands"))
+	}
+
+	for _, v := range p.args {
+		if !v.positional {
+			continue
+		}
+		if v.lname == name {
+			panic(fmt.Errorf("unable to add positional: name dup: %s", name))
+		}
+		if !v.unique {
+			panic(fmt.Errorf("unable to add positional: %s must come before variadic %s", name, v.name()))
+		}
+	}
+
+	a := &arg{
+		lname:        name,
+		value:        i,
+		defaultValue: defVal,
+		description:  description,
+		size:         1,
+		unique:       unique,
+		positional:   true,
+		opts:         opts,
+	}
+	p.args = append(p.args, a)
+	p.addToGroup(a)
+}
+
+// positionals returns the positional args in registration order
+func (p *Parser) positionals() []*arg {
+	res := make([]*arg, 0)
+	for _, v := range p.args {
+		if v.positional {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// bindPositionals hands leftover argv elements to the positional args in
+// order, a variadic positional takes everything that is left. The values no
+// positional took are returned.
+func (p *Parser) bindPositionals(values []string) ([]string, error) {
+	for _, v := range p.positionals() {
+		if len(values) == 0 {
+			break
+		}
+		n := 1
+		if !v.unique {
+			n = len(values)
+		}
+		if err := v.parse(values[:n]); err != nil {
+			return nil, err
+		}
+		values = values[n:]
+	}
+	return values, nil
+}
+
+func (p *Parser) PositionalString(defaultValue, name, description string, opts *Option) *string {
+	var result string
+
+	p.positionalVar(&result, defaultValue, name, description, true, opts)
+
+	return &result
+}
+func (p *Parser) PositionalStringVar(i *string, defaultValue, name, description string, opts *Option) {
+	p.positionalVar(i, defaultValue, name, description, true, opts)
+}
+
+func (p *Parser) PositionalInt(defaultValue int, name, description string, opts *Option) *int {
+	var result int
+
+	p.positionalVar(&result, defaultValue, name, description, true, opts)
+
+	return &result
+}
+func (p *Parser) PositionalIntVar(i *int, defaultValue int, name, description string, opts *Option) {
+	p.positionalVar(i, defaultValue, name, description, true, opts)
+}
+
+func (p *Parser) PositionalFloat(defaultValue float64, name, description string, opts *Option) *float64 {
+	var result float64
+
+	p.positionalVar(&result, defaultValue, name, description, true, opts)
+
+	return &result
+}
+func (p *Parser) PositionalFloatVar(i *float64, defaultValue float64, name, description string, opts *Option) {
+	p.positionalVar(i, defaultValue, name, description, true, opts)
+}
+
+func (p *Parser) PositionalStringSlice(defaultValue []string, name, description string, opts *Option) *[]string {
+	var result []string
+
+	p.positionalVar(&result, defaultValue, name, description, false, opts)
+
+	return &result
+}
+func (p *Parser) PositionalStringSliceVar(i *[]string, defaultValue []string, name, description string, opts *Option) {
+	p.positionalVar(i, defaultValue, name, description, false, opts)
+}
+
+func (p *Parser) PositionalIntSlice(defaultValue []int, name, description string, opts *Option) *[]int {
+	var result []int
+
+	p.positionalVar(&result, defaultValue, name, description, false, opts)
+
+	return &result
+}
+func (p *Parser) PositionalIntSliceVar(i *[]int, defaultValue []int, name, description string, opts *Option) {
+	p.positionalVar(i, defaultValue, name, description, false, opts)
+}
+
+func (p *Parser) PositionalFloatSlice(defaultValue []float64, name, description string, opts *Option) *[]float64 {
+	var result []float64
+
+	p.positionalVar(&result, defaultValue, name, description, false, opts)
+
+	return &result
+}
+func (p *Parser) PositionalFloatSliceVar(i *[]float64, defaultValue []float64, name, description string, opts *Option) {
+	p.positionalVar(i, defaultValue, name, description, false, opts)
+}
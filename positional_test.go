@@ -0,0 +1,117 @@
+package argparse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParser_bindPositionals(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantSrc   string
+		wantCount int
+		wantFiles []string
+		wantErr   bool
+	}{
+		{
+			name:      "test bind positionals in order",
+			args:      []string{"-v", "/src", "3", "a.txt", "b.txt"},
+			wantSrc:   "/src",
+			wantCount: 3,
+			wantFiles: []string{"a.txt", "b.txt"},
+		},
+		{
+			name:      "test bind positionals with defaults",
+			args:      []string{"/src"},
+			wantSrc:   "/src",
+			wantCount: 1,
+			wantFiles: []string{"default.txt"},
+		},
+		{
+			name:    "test bind positionals bad int value",
+			args:    []string{"/src", "three"},
+			wantErr: true,
+		},
+		{
+			name:    "test bind positionals missing required",
+			args:    []string{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			p.Bool(false, "v", "verbose", "", nil)
+			src := p.PositionalString("", "SRC", "source", &Option{Require: true})
+			count := p.PositionalInt(1, "COUNT", "copies", nil)
+			files := p.PositionalStringSlice([]string{"default.txt"}, "FILES", "files", nil)
+			err := p.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parser.Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if *src != tt.wantSrc || *count != tt.wantCount || !reflect.DeepEqual(*files, tt.wantFiles) {
+				t.Errorf("positionals = (%v, %v, %v), want (%v, %v, %v)", *src, *count, *files, tt.wantSrc, tt.wantCount, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func TestParser_positionalVar(t *testing.T) {
+	tests := []struct {
+		name      string
+		register  func(p *Parser)
+		wantPanic bool
+	}{
+		{
+			name: "test add positionals",
+			register: func(p *Parser) {
+				p.PositionalFloat(0, "RATIO", "", nil)
+				p.PositionalIntSlice(nil, "IDS", "", nil)
+			},
+		},
+		{
+			name: "test add positional after variadic",
+			register: func(p *Parser) {
+				p.PositionalFloatSlice(nil, "RATIOS", "", nil)
+				p.PositionalString("", "NAME", "", nil)
+			},
+			wantPanic: true,
+		},
+		{
+			name: "test add positional with duplicate name",
+			register: func(p *Parser) {
+				p.PositionalString("", "NAME", "", nil)
+				p.PositionalInt(0, "NAME", "", nil)
+			},
+			wantPanic: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); (r != nil) != tt.wantPanic {
+					t.Errorf("Parser.positionalVar() panic = %v, wantPanic %v", r, tt.wantPanic)
+				}
+			}()
+			tt.register(New())
+		})
+	}
+}
+
+func TestParser_usage(t *testing.T) {
+	p := New()
+	p.Bool(false, "v", "verbose", "", nil)
+	p.PositionalString("", "SRC", "", &Option{Require: true})
+	p.PositionalString("", "DST", "", nil)
+	p.PositionalStringSlice(nil, "FILES", "", nil)
+
+	want := "[options] SRC [DST] [FILES...]"
+	if got := p.usage(); got != want {
+		t.Errorf("Parser.usage() = %v, want %v", got, want)
+	}
+}
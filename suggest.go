@@ -0,0 +1,117 @@
+package argparse
+
+import (
+	"sort"
+	"strings"
+)
+
+// defaultSuggestDistance is the largest edit distance suggested by default
+const defaultSuggestDistance = 2
+
+// SetSuggestions turns "did you mean" suggestions for unknown names on or
+// off, they are on by default. Set on the root parser it covers all commands.
+func (p *Parser) SetSuggestions(enabled bool) {
+	p.noSuggest = !enabled
+}
+
+// SetSuggestionDistance sets the largest edit distance between an unknown
+// name and a registered one for the latter to be suggested, 2 by default.
+// Set on the root parser it covers all commands.
+func (p *Parser) SetSuggestionDistance(n int) {
+	p.suggestDistance = n
+}
+
+// suggestOptions returns the registered option names closest to the unknown
+// option s, as they would be written on the command line
+func (p *Parser) suggestOptions(s string) []string {
+	if p.root().noSuggest {
+		return nil
+	}
+
+	candidates := make([]string, 0)
+	for _, k := range p.longNames() {
+		candidates = append(candidates, "--"+k)
+	}
+	for _, k := range p.shortNames() {
+		candidates = append(candidates, "-"+k)
+	}
+
+	return p.suggest(s, candidates, func(c string) string {
+		return strings.TrimLeft(c, "-")
+	})
+}
+
+// suggest returns the candidates within the suggestion distance of s,
+// closest first. bare strips decorations like dashes before comparing.
+func (p *Parser) suggest(s string, candidates []string, bare func(string) string) []string {
+	max := p.root().suggestDistance
+	if max <= 0 {
+		max = defaultSuggestDistance
+	}
+
+	type match struct {
+		name string
+		dist int
+	}
+	matches := make([]match, 0)
+	s = bare(s)
+	for _, c := range candidates {
+		b := bare(c)
+		// a one letter name is within reach of anything short, skip those
+		if d := distance(s, b); d <= max && d < len(b) {
+			matches = append(matches, match{name: c, dist: d})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].dist != matches[j].dist {
+			return matches[i].dist < matches[j].dist
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	res := make([]string, 0, len(matches))
+	for _, m := range matches {
+		res = append(res, m.name)
+	}
+	return res
+}
+
+// distance is the optimal string alignment distance between a and b, the
+// Levenshtein distance with adjacent transpositions counted as one edit
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min3(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] && d[i-2][j-2]+1 < d[i][j] {
+				d[i][j] = d[i-2][j-2] + 1
+			}
+		}
+	}
+
+	return d[len(ra)][len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,66 @@
+package argparse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_distance(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want int
+	}{
+		{name: "test distance equal", a: "port", b: "port", want: 0},
+		{name: "test distance transposition", a: "prot", b: "port", want: 1},
+		{name: "test distance insertion", a: "prt", b: "port", want: 1},
+		{name: "test distance empty", a: "", b: "port", want: 4},
+		{name: "test distance different", a: "config", b: "port", want: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := distance(tt.a, tt.b); got != tt.want {
+				t.Errorf("distance() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_suggestOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		disable  bool
+		distance int
+		want     []string
+	}{
+		{name: "test suggest long name", s: "--prot", want: []string{"--port", "--sort"}},
+		{name: "test suggest short name written as long", s: "--pp", want: []string{"-pp"}},
+		{name: "test suggest nothing close", s: "--config", want: []string{}},
+		{name: "test suggest with smaller distance", s: "--prot", distance: 1, want: []string{"--port"}},
+		{name: "test suggest disabled", s: "--prot", disable: true, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			p.Int(0, "pp", "port", "", nil)
+			p.Bool(false, "", "sort", "", nil)
+			p.SetSuggestions(!tt.disable)
+			p.SetSuggestionDistance(tt.distance)
+			if got := p.suggestOptions(tt.s); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parser.suggestOptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnknownOptionError_Error(t *testing.T) {
+	p := New()
+	p.Int(0, "p", "port", "", nil)
+	err := p.Parse([]string{"--prot", "3000"})
+	want := "unknown option --prot, did you mean --port?"
+	if err == nil || err.Error() != want {
+		t.Errorf("Parser.Parse() error = %v, want %v", err, want)
+	}
+}
@@ -0,0 +1,186 @@
+package argparse
+
+import (
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// HelpData is the data model help templates are executed with
+type HelpData struct {
+	// Prog is the program name
+	Prog string
+	// Command is the path of the command help is shown for, "" for the root
+	Command string
+	// Usage is the synopsis, e.g. "tool [options] SRC FILES..."
+	Usage       string
+	Description string
+	// Groups holds the options in help sections. The first one has no title
+	// when there are options outside of any Group.
+	Groups []HelpGroup
+	// Inherited holds persistent options of parent commands
+	Inherited []HelpArg
+	Commands  []HelpCommand
+	// Plugins lists the commands of external plugins found on PATH
+	Plugins  []string
+	Examples []HelpExample
+	Epilog   string
+	// Width is the width help should be wrapped to
+	Width int
+}
+
+// HelpGroup is a help section of options
+type HelpGroup struct {
+	Title       string
+	Description string
+	Args        []HelpArg
+}
+
+// HelpArg describes an option or positional argument
+type HelpArg struct {
+	// Name is the rendered name, e.g. "-p, --port PORT"
+	Name string
+	// Short and Long are the names without dashes, Long holds the name of a
+	// positional
+	Short   string
+	Long    string
+	Metavar string
+	// Type is the value type, e.g. "int" or "[]string"
+	Type       string
+	Default    string
+	Required   bool
+	Positional bool
+	Choices    []string
+	// Env is the environment variable the value is read from
+	Env         string
+	Description string
+	// Notes are the remarks the default help appends to the description,
+	// e.g. "default: 3000"
+	Notes []string
+}
+
+// HelpExample is a command line with a description of what it does
+type HelpExample struct {
+	Command     string
+	Description string
+}
+
+// HelpCommand describes a subcommand
+type HelpCommand struct {
+	Name        string
+	Aliases     []string
+	Description string
+}
+
+// HelpFuncs returns the functions available to help templates:
+//
+//	wrap WIDTH TEXT     wraps TEXT to lines of at most WIDTH characters
+//	indent N TEXT       indents every line of TEXT by N spaces
+//	pad WIDTH TEXT      pads TEXT with spaces to WIDTH characters
+//	join SEP LIST       joins a list of strings
+//	upper TEXT          upper cases TEXT
+func HelpFuncs() template.FuncMap {
+	return template.FuncMap{
+		"wrap": func(width int, s string) string {
+			return strings.Join(wrap(s, width), "\n")
+		},
+		"indent": func(n int, s string) string {
+			prefix := strings.Repeat(" ", n)
+			return prefix + strings.Replace(s, "\n", "\n"+prefix, -1)
+		},
+		"pad": func(width int, s string) string {
+			if utf8.RuneCountInString(s) >= width {
+				return s
+			}
+			return s + strings.Repeat(" ", width-utf8.RuneCountInString(s))
+		},
+		"join": func(sep string, list []string) string {
+			return strings.Join(list, sep)
+		},
+		"upper": strings.ToUpper,
+	}
+}
+
+// SetHelpTemplate replaces the built-in help layout with a text/template
+// executed with HelpData, HelpFuncs are available to it. It covers the
+// parser and the commands below it that have no template of their own.
+func (p *Parser) SetHelpTemplate(text string) error {
+	t, err := template.New("help").Funcs(HelpFuncs()).Parse(text)
+	if err != nil {
+		return err
+	}
+	p.helpTemplate = t
+	return nil
+}
+
+func (p *Parser) helpArg(a *arg) HelpArg {
+	h := HelpArg{
+		Name:        a.helpName(),
+		Short:       a.sname,
+		Long:        a.lname,
+		Metavar:     a.metavar(),
+		Type:        a.getType(),
+		Default:     a.defaultText(),
+		Positional:  a.positional,
+		Description: a.description,
+		Notes:       p.notes(a),
+	}
+	if a.opts != nil {
+		h.Required = a.opts.Require
+		h.Choices = a.opts.Choices
+		h.Env = a.opts.Env
+	}
+	return h
+}
+
+// helpData builds the template data model of the help of p
+func (p *Parser) helpData() *HelpData {
+	d := &HelpData{
+		Prog:        p.prog(),
+		Command:     p.path(),
+		Usage:       p.synopsis(),
+		Description: p.description,
+		Plugins:     p.discoverPlugins(),
+		Epilog:      p.epilog,
+		Width:       p.helpWidth(),
+	}
+
+	for _, sec := range p.sections() {
+		g := HelpGroup{Title: sec.title, Description: sec.description}
+		for _, v := range sec.args {
+			g.Args = append(g.Args, p.helpArg(v))
+		}
+		d.Groups = append(d.Groups, g)
+	}
+	for _, v := range p.inherited() {
+		d.Inherited = append(d.Inherited, p.helpArg(v))
+	}
+	for _, c := range p.visibleCommands() {
+		d.Commands = append(d.Commands, HelpCommand{Name: c.name, Aliases: c.aliases, Description: c.description})
+	}
+	for _, e := range p.examples {
+		d.Examples = append(d.Examples, HelpExample{Command: e.command, Description: e.description})
+	}
+
+	return d
+}
+
+// helpTemplateOf returns the template of p or of its nearest ancestor that
+// has one, nil when none has
+func (p *Parser) helpTemplateOf() *template.Template {
+	for c := p; c != nil; c = c.parent {
+		if c.helpTemplate != nil {
+			return c.helpTemplate
+		}
+	}
+	return nil
+}
+
+// executeHelp renders help with the nearest template
+func (p *Parser) executeHelp() (string, error) {
+	sb := &strings.Builder{}
+	if err := p.helpTemplateOf().Execute(sb, p.helpData()); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,119 @@
+package argparse
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParser_SetHelpTemplate(t *testing.T) {
+	tmpl := `{{.Usage}}
+{{range .Groups}}{{if .Title}}[{{.Title}}]
+{{end}}{{range .Args}}{{pad 16 .Name}}{{.Type}}{{if .Required}} *{{end}}{{if .Default}} ={{.Default}}{{end}}
+{{end}}{{end}}{{range .Commands}}{{.Name}}: {{join "," .Aliases}}
+{{end}}{{wrap 10 .Description | indent 2}}
+`
+	buf := &bytes.Buffer{}
+	p := New()
+	p.SetProg("tool")
+	p.SetDescription("deploys the service everywhere")
+	p.SetOutput(buf)
+	if err := p.SetHelpTemplate(tmpl); err != nil {
+		t.Fatalf("Parser.SetHelpTemplate() error = %v", err)
+	}
+	network := p.Group("Network", "")
+	p.Help("h", "help")
+	p.Int(3000, "p", "port", "", &Option{Group: network, Require: true})
+	p.AddCommand("remove", "").AddAlias("rm", "del")
+
+	if err := p.Parse([]string{"-h"}); err != ErrHelp {
+		t.Fatalf("Parser.Parse() error = %v, want %v", err, ErrHelp)
+	}
+	want := `tool [options] <command>
+-h, --help      bool
+[Network]
+-p, --port PORT int * =3000
+remove: rm,del
+  deploys
+  the
+  service
+  everywhere
+`
+	if got := buf.String(); got != want {
+		t.Errorf("help =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestParser_SetHelpTemplateErrors(t *testing.T) {
+	p := New()
+	if err := p.SetHelpTemplate("{{.Usage"); err == nil {
+		t.Errorf("Parser.SetHelpTemplate() error = nil, want parse error")
+	}
+
+	p.SetOutput(&bytes.Buffer{})
+	p.Help("h", "help")
+	if err := p.SetHelpTemplate("{{.Missing}}"); err != nil {
+		t.Fatalf("Parser.SetHelpTemplate() error = %v", err)
+	}
+	if err := p.Parse([]string{"-h"}); err == nil || err == ErrHelp {
+		t.Errorf("Parser.Parse() error = %v, want execution error", err)
+	}
+}
+
+func TestParser_helpData(t *testing.T) {
+	p := New()
+	p.Bool(false, "v", "verbose", "verbose output", &Option{Persistent: true})
+	deploy := p.AddCommand("deploy", "deploy the service")
+	deploy.String("dev", "e", "env", "target", &Option{Choices: []string{"dev", "prod"}})
+
+	d := deploy.helpData()
+	if d.Command != "deploy" || d.Description != "deploy the service" {
+		t.Errorf("HelpData = %+v", d)
+	}
+	if len(d.Groups) != 1 || len(d.Groups[0].Args) != 1 {
+		t.Fatalf("HelpData.Groups = %+v", d.Groups)
+	}
+	env := d.Groups[0].Args[0]
+	if env.Long != "env" || env.Metavar != "ENV" || env.Default != "dev" || len(env.Choices) != 2 {
+		t.Errorf("HelpData arg = %+v", env)
+	}
+	if len(d.Inherited) != 1 || d.Inherited[0].Long != "verbose" {
+		t.Errorf("HelpData.Inherited = %+v", d.Inherited)
+	}
+}
+
+func TestParser_helpTemplateOf(t *testing.T) {
+	tests := []struct {
+		name string
+		root string
+		cmd  string
+		want string
+	}{
+		{name: "test template of command", cmd: "cmd {{.Command}}\n", want: "cmd deploy\n"},
+		{name: "test template inherited", root: "root {{.Command}}\n", want: "root deploy\n"},
+		{name: "test template of command wins", root: "root\n", cmd: "cmd\n", want: "cmd\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			p := New()
+			p.SetOutput(buf)
+			p.Help("h", "help")
+			deploy := p.AddCommand("deploy", "")
+			for c, text := range map[*Parser]string{p: tt.root, deploy: tt.cmd} {
+				if text == "" {
+					continue
+				}
+				if err := c.SetHelpTemplate(text); err != nil {
+					t.Fatalf("Parser.SetHelpTemplate() error = %v", err)
+				}
+			}
+
+			if err := p.Parse([]string{"deploy", "-h"}); err != ErrHelp {
+				t.Fatalf("Parser.Parse() error = %v, want %v", err, ErrHelp)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("help = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
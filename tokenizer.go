@@ -0,0 +1,281 @@
+package argparse
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	// optionToken is a registered option together with the values it consumed
+	optionToken tokenKind = iota
+	// argumentToken is an argv element no option claimed
+	argumentToken
+	// unknownToken looks like an option but matches no registered name
+	unknownToken
+	// commandToken selects a subcommand, its values are the argv after it
+	commandToken
+	// pluginToken selects an external command, its name is the executable
+	// path and its values are the argv after it
+	pluginToken
+)
+
+type token struct {
+	kind tokenKind
+	// option as written in argv, e.g. "--port" or "-p"
+	name   string
+	arg    *arg
+	values []string
+	// index of the token in argv
+	pos int
+	// argument found after the "--" end-of-options marker
+	literal bool
+}
+
+// indexArg registers the names of a in the parser lookup tables
+func (p *Parser) indexArg(a *arg) error {
+	if p.snames == nil {
+		p.snames = make(map[string]*arg)
+	}
+	if p.lnames == nil {
+		p.lnames = make(map[string]*arg)
+	}
+
+	if _, ok := p.snames[a.sname]; a.sname != "" && ok {
+		return fmt.Errorf("option name dup: -%s", a.sname)
+	}
+	if _, ok := p.lnames[a.lname]; a.lname != "" && ok {
+		return fmt.Errorf("option name dup: --%s", a.lname)
+	}
+
+	if a.negatable() {
+		if _, ok := p.lnames["no-"+a.lname]; ok {
+			return fmt.Errorf("option name dup: --no-%s", a.lname)
+		}
+		p.lnames["no-"+a.lname] = a
+	}
+
+	if a.sname != "" {
+		p.snames[a.sname] = a
+	}
+	if a.lname != "" {
+		p.lnames[a.lname] = a
+	}
+
+	return nil
+}
+
+// lookup resolves a single argv element to a registered option. A value
+// attached as "--name=value" is returned separately, with attached
+// reporting whether there was one. Short options other than an exact
+// match are left to cluster.
+func (p *Parser) lookup(s string) (a *arg, name, value string, attached bool) {
+	if len(s) > 2 && strings.HasPrefix(s, "--") && s[2] != '-' {
+		name = s[2:]
+		if i := strings.IndexByte(name, '='); i >= 0 {
+			name, value, attached = name[:i], name[i+1:], true
+		}
+		return p.longArg(name), "--" + name, value, attached
+	}
+
+	if isShort(s) {
+		return p.shortArg(s[1:]), s, "", false
+	}
+
+	return nil, "", "", false
+}
+
+// abbreviation resolves a long option written as an unambiguous prefix of
+// its name, e.g. "--conf" for "--config". The full name is returned along
+// with the option, so "--no-ca" reads as "--no-cache".
+func (p *Parser) abbreviation(name string) (*arg, string, error) {
+	prefix := strings.TrimPrefix(name, "--")
+	if prefix == "" {
+		return nil, "", nil
+	}
+
+	candidates := make([]string, 0)
+	for _, k := range p.longNames() {
+		if strings.HasPrefix(k, prefix) {
+			candidates = append(candidates, k)
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil, "", nil
+	case 1:
+		return p.longArg(candidates[0]), "--" + candidates[0], nil
+	}
+
+	sort.Strings(candidates)
+	for i := range candidates {
+		candidates[i] = "--" + candidates[i]
+	}
+	return nil, "", &AmbiguousOptionError{Option: name, Candidates: candidates}
+}
+
+// looksLikeOption reports whether s is dashed like an option. A lone "-"
+// and negative numbers are plain arguments.
+func looksLikeOption(s string) bool {
+	if len(s) < 2 || s[0] != '-' {
+		return false
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return false
+	}
+	return true
+}
+
+func isShort(s string) bool {
+	return len(s) > 1 && s[0] == '-' && s[1] != '-'
+}
+
+// shortPrefix returns the option with the longest short name s starts with
+func (p *Parser) shortPrefix(s string) (*arg, int) {
+	for n := len(s); n > 0; n-- {
+		if a := p.shortArg(s[:n]); a != nil {
+			return a, n
+		}
+	}
+	return nil, 0
+}
+
+// isCluster reports whether s is made up of short names only
+func (p *Parser) isCluster(s string) bool {
+	for len(s) > 0 {
+		_, n := p.shortPrefix(s)
+		if n == 0 {
+			return false
+		}
+		s = s[n:]
+	}
+	return true
+}
+
+// cluster expands a run of short options like "-xvf" or "-p3000". All but
+// the last option must be flags; the last one may take its value attached
+// ("-vp3000") or from the following argv elements. A value option followed
+// by registered flags only, like "-xcv", is an error rather than taking
+// them as its value. A nil result means s is
+// not a cluster of registered options.
+func (p *Parser) cluster(s string) (args []*arg, names []string, value string, attached bool, err error) {
+	body := s[1:]
+	for len(body) > 0 {
+		a, n := p.shortPrefix(body)
+		if a == nil {
+			return nil, nil, "", false, nil
+		}
+		args = append(args, a)
+		names = append(names, "-"+body[:n])
+		body = body[n:]
+
+		if a.size == 0 || body == "" {
+			continue
+		}
+		if len(args) > 1 && p.isCluster(body) {
+			return nil, nil, "", false, fmt.Errorf("[%s] takes a value and must be the last option in %s", a.name(), s)
+		}
+		return args, names, body, true, nil
+	}
+
+	return args, names, "", false, nil
+}
+
+// tokenize walks argv once from left to right and turns it into a token stream
+func (p *Parser) tokenize(args []string) ([]token, error) {
+	tokens := make([]token, 0, len(args))
+	argSeen := false
+
+	for i := 0; i < len(args); i++ {
+		s := args[i]
+
+		if s == "--" {
+			for j := i + 1; j < len(args); j++ {
+				tokens = append(tokens, token{kind: argumentToken, values: args[j : j+1], pos: j, literal: true})
+			}
+			break
+		}
+
+		a, name, value, attached := p.lookup(s)
+		if a == nil && p.root().abbrev && strings.HasPrefix(s, "--") {
+			var (
+				full string
+				err  error
+			)
+			if a, full, err = p.abbreviation(name); err != nil {
+				return nil, err
+			}
+			if a != nil {
+				name = full
+			}
+		}
+		if a == nil && isShort(s) {
+			as, names, v, att, err := p.cluster(s)
+			if err != nil {
+				return nil, err
+			}
+			for j := 0; j < len(as)-1; j++ {
+				tokens = append(tokens, token{kind: optionToken, name: names[j], arg: as[j], values: []string{}, pos: i})
+			}
+			if len(as) > 0 {
+				a, name, value, attached = as[len(as)-1], names[len(names)-1], v, att
+			}
+		}
+		if a == nil && looksLikeOption(s) {
+			name = s
+			if eq := strings.IndexByte(s, '='); eq > 0 && strings.HasPrefix(s, "--") {
+				name = s[:eq]
+			}
+			tokens = append(tokens, token{kind: unknownToken, name: name, values: args[i : i+1], pos: i})
+			continue
+		}
+		if a == nil {
+			// the first argument may select a command or plugin
+			if !argSeen && (len(p.commands) > 0 || (p.root().plugins && len(p.positionals()) == 0)) {
+				if p.findCommand(s) != nil {
+					tokens = append(tokens, token{kind: commandToken, name: s, values: args[i+1:], pos: i})
+					break
+				}
+				if path, ok := p.lookPlugin(s); ok {
+					tokens = append(tokens, token{kind: pluginToken, name: path, values: args[i+1:], pos: i})
+					break
+				}
+				if len(p.commands) > 0 {
+					return nil, &UnknownCommandError{Command: s, Suggestions: p.suggestCommands(s)}
+				}
+			}
+			argSeen = true
+			tokens = append(tokens, token{kind: argumentToken, values: args[i : i+1], pos: i})
+			continue
+		}
+
+		size := a.size
+		values := make([]string, 0, size)
+		negated := a.negatable() && name == "--no-"+a.lname
+		if attached {
+			if _, ok := a.value.(*bool); size == 0 && (!ok || negated) {
+				return nil, fmt.Errorf("[%s] does not take a value", a.name())
+			}
+			values = append(values, value)
+			if size > 0 {
+				size--
+			}
+		}
+		if negated {
+			values = append(values, "false")
+		}
+
+		if len(args) < i+1+size {
+			return nil, fmt.Errorf("no enough arguments for %s", a.name())
+		}
+		values = append(values, args[i+1:i+1+size]...)
+		tokens = append(tokens, token{kind: optionToken, name: name, arg: a, values: values, pos: i})
+		i += size
+	}
+
+	return tokens, nil
+}
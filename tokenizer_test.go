@@ -0,0 +1,323 @@
+package argparse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParser_lookup(t *testing.T) {
+	var s string
+	var b bool
+	c := &arg{sname: "c", lname: "config", size: 1, unique: true, value: &s}
+	v := &arg{sname: "v", size: 0, unique: true, value: &b}
+	p := New()
+	for _, a := range []*arg{c, v} {
+		if err := p.addArg(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name         string
+		s            string
+		want         *arg
+		wantName     string
+		wantValue    string
+		wantAttached bool
+	}{
+		{name: "test lookup short name", s: "-c", want: c, wantName: "-c"},
+		{name: "test lookup long name", s: "--config", want: c, wantName: "--config"},
+		{name: "test lookup unknown name", s: "--conf", want: nil},
+		{name: "test lookup long name with short prefix", s: "-config", want: nil},
+		{name: "test lookup triple dash", s: "---config", want: nil},
+		{name: "test lookup plain argument", s: "config", want: nil},
+		{name: "test lookup single dash", s: "-", want: nil},
+		{name: "test lookup long name with value", s: "--config=/a=b", want: c, wantName: "--config", wantValue: "/a=b", wantAttached: true},
+		{name: "test lookup long name with empty value", s: "--config=", want: c, wantName: "--config", wantValue: "", wantAttached: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotName, gotValue, gotAttached := p.lookup(tt.s)
+			if got != tt.want {
+				t.Errorf("Parser.lookup() = %v, want %v", got, tt.want)
+			}
+			if got == nil {
+				return
+			}
+			if gotName != tt.wantName || gotValue != tt.wantValue || gotAttached != tt.wantAttached {
+				t.Errorf("Parser.lookup() = (%q, %q, %v), want (%q, %q, %v)", gotName, gotValue, gotAttached, tt.wantName, tt.wantValue, tt.wantAttached)
+			}
+		})
+	}
+}
+
+func TestParser_abbreviation(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		abbrev  bool
+		want    string
+		wantErr bool
+	}{
+		{name: "test abbreviation unique prefix", args: []string{"--conf", "a.yml"}, abbrev: true, want: "a.yml"},
+		{name: "test abbreviation with attached value", args: []string{"--conf=a.yml"}, abbrev: true, want: "a.yml"},
+		{name: "test abbreviation exact match wins", args: []string{"--co", "a.yml"}, abbrev: true},
+		{name: "test abbreviation ambiguous prefix", args: []string{"--c", "a.yml"}, abbrev: true, wantErr: true},
+		{name: "test abbreviation disabled", args: []string{"--conf", "a.yml"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			config := p.String("", "", "config", "", nil)
+			p.Bool(false, "", "color", "", nil)
+			p.String("", "", "co", "", nil)
+			p.SetAbbreviations(tt.abbrev)
+			err := p.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parser.Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if *config != tt.want {
+				t.Errorf("config = %v, want %v", *config, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmbiguousOptionError_Error(t *testing.T) {
+	p := New()
+	p.String("", "", "config", "", nil)
+	p.Bool(false, "", "color", "", nil)
+	p.SetAbbreviations(true)
+	err := p.Parse([]string{"--co"})
+	want := "ambiguous option --co could match --color, --config"
+	if err == nil || err.Error() != want {
+		t.Errorf("Parser.Parse() error = %v, want %v", err, want)
+	}
+}
+
+func TestParser_ParseNegatable(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		abbrev  bool
+		want    bool
+		wantErr bool
+	}{
+		{name: "test negatable default", args: []string{}, want: true},
+		{name: "test negatable negated", args: []string{"--no-cache"}, want: false},
+		{name: "test negatable set", args: []string{"--cache"}, want: true},
+		{name: "test negatable explicit false", args: []string{"--cache=false"}, want: false},
+		{name: "test negatable negated with value", args: []string{"--no-cache=true"}, wantErr: true},
+		{name: "test negatable given twice", args: []string{"--cache", "--no-cache"}, wantErr: true},
+		{name: "test negatable abbreviated negation", args: []string{"--no-ca"}, abbrev: true, want: false},
+		{name: "test negatable abbreviated", args: []string{"--ca"}, abbrev: true, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			p.SetAbbreviations(tt.abbrev)
+			cache := p.Bool(true, "c", "cache", "", &Option{Negatable: true})
+			err := p.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parser.Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && *cache != tt.want {
+				t.Errorf("cache = %v, want %v", *cache, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_cluster(t *testing.T) {
+	var s, port string
+	var x, v bool
+	c := &arg{sname: "c", size: 1, unique: true, value: &s}
+	pa := &arg{sname: "p", size: 1, unique: true, value: &port}
+	xa := &arg{sname: "x", size: 0, unique: true, value: &x}
+	va := &arg{sname: "v", size: 0, unique: true, value: &v}
+	p := New()
+	for _, a := range []*arg{c, pa, xa, va} {
+		if err := p.addArg(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name         string
+		s            string
+		wantArgs     []*arg
+		wantNames    []string
+		wantValue    string
+		wantAttached bool
+		wantErr      bool
+	}{
+		{
+			name:      "test cluster of flags",
+			s:         "-xv",
+			wantArgs:  []*arg{xa, va},
+			wantNames: []string{"-x", "-v"},
+		},
+		{
+			name:      "test cluster ending with value option",
+			s:         "-xvc",
+			wantArgs:  []*arg{xa, va, c},
+			wantNames: []string{"-x", "-v", "-c"},
+		},
+		{
+			name:         "test cluster with attached value",
+			s:            "-xc/config.yml",
+			wantArgs:     []*arg{xa, c},
+			wantNames:    []string{"-x", "-c"},
+			wantValue:    "/config.yml",
+			wantAttached: true,
+		},
+		{
+			name:         "test short name with attached value",
+			s:            "-cv",
+			wantArgs:     []*arg{c},
+			wantNames:    []string{"-c"},
+			wantValue:    "v",
+			wantAttached: true,
+		},
+		{
+			name:    "test cluster with value option in the middle",
+			s:       "-xcv",
+			wantErr: true,
+		},
+		{
+			name:    "test cluster value spelled like a flag",
+			s:       "-vpx",
+			wantErr: true,
+		},
+		{
+			name:         "test cluster value not a flag",
+			s:            "-vpy",
+			wantArgs:     []*arg{va, pa},
+			wantNames:    []string{"-v", "-p"},
+			wantValue:    "y",
+			wantAttached: true,
+		},
+		{
+			name:         "test cluster with attached number",
+			s:            "-vp3000",
+			wantArgs:     []*arg{va, pa},
+			wantNames:    []string{"-v", "-p"},
+			wantValue:    "3000",
+			wantAttached: true,
+		},
+		{
+			name: "test cluster with unknown option",
+			s:    "-xy",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotArgs, gotNames, gotValue, gotAttached, err := p.cluster(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parser.cluster() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) || !reflect.DeepEqual(gotNames, tt.wantNames) {
+				t.Errorf("Parser.cluster() = (%v, %v), want (%v, %v)", gotArgs, gotNames, tt.wantArgs, tt.wantNames)
+			}
+			if gotValue != tt.wantValue || gotAttached != tt.wantAttached {
+				t.Errorf("Parser.cluster() value = (%q, %v), want (%q, %v)", gotValue, gotAttached, tt.wantValue, tt.wantAttached)
+			}
+		})
+	}
+}
+
+func TestParser_tokenize(t *testing.T) {
+	var s string
+	var b bool
+	c := &arg{sname: "c", lname: "config", size: 1, unique: true, value: &s}
+	v := &arg{sname: "v", lname: "verbose", size: 0, unique: true, value: &b}
+	p := New()
+	for _, a := range []*arg{c, v} {
+		if err := p.addArg(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name    string
+		args    []string
+		want    []token
+		wantErr bool
+	}{
+		{
+			name: "test tokenize options and arguments",
+			args: []string{"-v", "file", "--config", "/config.yml"},
+			want: []token{
+				{kind: optionToken, name: "-v", arg: v, values: []string{}, pos: 0},
+				{kind: argumentToken, values: []string{"file"}, pos: 1},
+				{kind: optionToken, name: "--config", arg: c, values: []string{"/config.yml"}, pos: 2},
+			},
+		},
+		{
+			name: "test tokenize value looking like option",
+			args: []string{"-c", "-v"},
+			want: []token{
+				{kind: optionToken, name: "-c", arg: c, values: []string{"-v"}, pos: 0},
+			},
+		},
+		{
+			name: "test tokenize attached values",
+			args: []string{"--config=/config.yml", "-c/etc/config.yml"},
+			want: []token{
+				{kind: optionToken, name: "--config", arg: c, values: []string{"/config.yml"}, pos: 0},
+				{kind: optionToken, name: "-c", arg: c, values: []string{"/etc/config.yml"}, pos: 1},
+			},
+		},
+		{
+			name: "test tokenize cluster with value from next element",
+			args: []string{"-vc", "/config.yml"},
+			want: []token{
+				{kind: optionToken, name: "-v", arg: v, values: []string{}, pos: 0},
+				{kind: optionToken, name: "-c", arg: c, values: []string{"/config.yml"}, pos: 0},
+			},
+		},
+		{
+			name: "test tokenize end of options marker",
+			args: []string{"-v", "--", "-c", "--"},
+			want: []token{
+				{kind: optionToken, name: "-v", arg: v, values: []string{}, pos: 0},
+				{kind: argumentToken, values: []string{"-c"}, pos: 2, literal: true},
+				{kind: argumentToken, values: []string{"--"}, pos: 3, literal: true},
+			},
+		},
+		{
+			name: "test tokenize unknown options",
+			args: []string{"--conf=/config.yml", "-y", "-", "-1.5"},
+			want: []token{
+				{kind: unknownToken, name: "--conf", values: []string{"--conf=/config.yml"}, pos: 0},
+				{kind: unknownToken, name: "-y", values: []string{"-y"}, pos: 1},
+				{kind: argumentToken, values: []string{"-"}, pos: 2},
+				{kind: argumentToken, values: []string{"-1.5"}, pos: 3},
+			},
+		},
+		{
+			name: "test tokenize value attached to bool",
+			args: []string{"--verbose=0"},
+			want: []token{
+				{kind: optionToken, name: "--verbose", arg: v, values: []string{"0"}, pos: 0},
+			},
+		},
+		{
+			name:    "test tokenize missing value",
+			args:    []string{"-v", "-c"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.tokenize(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parser.tokenize() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parser.tokenize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
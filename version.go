@@ -0,0 +1,60 @@
+package argparse
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// Version adds the option printing the program version, like Help it stops
+// parsing before required options are checked and Parse returns ErrVersion.
+// An empty version is taken from the build info of the main module.
+func (p *Parser) Version(short, long, version string) {
+	p.builtinArg(&arg{sname: short, lname: long, description: "show version", version: true})
+	p.versionText = version
+}
+
+// SetVersionBuildInfo adds the module path and checksum and the Go version
+// the program was built with to the version output
+func (p *Parser) SetVersionBuildInfo(enabled bool) {
+	p.versionBuildInfo = enabled
+}
+
+// versionOwner returns the parser Version was called on, p or an ancestor
+func (p *Parser) versionOwner() *Parser {
+	for c := p; c != nil; c = c.parent {
+		for _, v := range c.args {
+			if v.version {
+				return c
+			}
+		}
+	}
+	return p
+}
+
+func (p *Parser) versionString() string {
+	o := p.versionOwner()
+	info, ok := debug.ReadBuildInfo()
+
+	version := o.versionText
+	if version == "" && ok {
+		version = info.Main.Version
+	}
+
+	sb := &strings.Builder{}
+	sb.WriteString(strings.TrimSpace(p.prog() + " " + version))
+	sb.WriteString("\n")
+	if o.versionBuildInfo {
+		if ok {
+			sb.WriteString(fmt.Sprintf("module %s %s %s\n", info.Main.Path, info.Main.Version, info.Main.Sum))
+		}
+		sb.WriteString(fmt.Sprintf("go %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH))
+	}
+	return sb.String()
+}
+
+func (p *Parser) printVersion() error {
+	_, err := fmt.Fprint(p.Output(), p.versionString())
+	return err
+}
@@ -0,0 +1,84 @@
+package argparse
+
+import (
+	"bytes"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParser_Version(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		buildInfo bool
+		wantErr   error
+		wantOut   []string
+	}{
+		{name: "test print version", args: []string{"--version"}, wantErr: ErrVersion, wantOut: []string{"tool 1.2.3\n"}},
+		{name: "test short name", args: []string{"-V"}, wantErr: ErrVersion, wantOut: []string{"tool 1.2.3\n"}},
+		{name: "test skip required", args: []string{"deploy", "--version"}, wantErr: ErrVersion, wantOut: []string{"tool 1.2.3\n"}},
+		{name: "test build info", args: []string{"-V"}, buildInfo: true, wantErr: ErrVersion, wantOut: []string{"tool 1.2.3\n", "go " + runtime.Version()}},
+		{name: "test help wins", args: []string{"-V", "-h"}, wantErr: ErrHelp, wantOut: []string{"Usage: tool"}},
+		{name: "test not given", args: []string{"-n", "1"}, wantErr: nil, wantOut: []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			p := New()
+			p.SetProg("tool")
+			p.SetOutput(buf)
+			p.Help("h", "help")
+			p.Version("V", "version", "1.2.3")
+			p.SetVersionBuildInfo(tt.buildInfo)
+			p.Int(0, "n", "num", "", &Option{Require: true})
+			deploy := p.AddCommand("deploy", "")
+			deploy.String("", "t", "target", "", &Option{Require: true})
+
+			if err := p.Parse(tt.args); err != tt.wantErr {
+				t.Errorf("Parser.Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, want := range tt.wantOut {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("output = %q, want %q", buf.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestParser_VersionExit(t *testing.T) {
+	defer func() { exit = os.Exit }()
+
+	code := -1
+	exit = func(c int) { code = c }
+
+	p := New()
+	p.SetOutput(&bytes.Buffer{})
+	p.SetErrorHandling(ExitOnError)
+	p.Version("", "version", "1.2.3")
+	if err := p.Parse([]string{"--version"}); err != ErrVersion {
+		t.Errorf("Parser.Parse() error = %v, wantErr %v", err, ErrVersion)
+	}
+	if code != 0 {
+		t.Errorf("exit code = %v, want 0", code)
+	}
+}
+
+func TestParser_VersionOnCommands(t *testing.T) {
+	buf := &bytes.Buffer{}
+	p := New()
+	p.SetProg("tool")
+	p.SetOutput(buf)
+	deploy := p.AddCommand("deploy", "")
+	deploy.Version("V", "version", "2.0.0")
+	p.Version("V", "version", "1.0.0")
+
+	if err := p.Parse([]string{"deploy", "-V"}); err != ErrVersion {
+		t.Fatalf("Parser.Parse() error = %v, wantErr %v", err, ErrVersion)
+	}
+	if got, want := buf.String(), "tool 2.0.0\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}